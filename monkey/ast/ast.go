@@ -17,7 +17,8 @@ type ExpressionStatement struct {
 	Expression Expression
 }
 
-func (es *ExpressionStatement) statementNode() {}
+func (es *ExpressionStatement) statementNode()       {}
+func (es *ExpressionStatement) Pos() mtoken.Position { return es.Token.Pos }
 
 func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
@@ -37,6 +38,7 @@ type PrefixExpression struct {
 }
 
 func (pe *PrefixExpression) expressionNode()      {}
+func (pe *PrefixExpression) Pos() mtoken.Position { return pe.Token.Pos }
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
@@ -51,6 +53,7 @@ func (pe *PrefixExpression) String() string {
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() mtoken.Position // ノードの先頭の位置
 }
 
 type Statement interface {
@@ -67,6 +70,15 @@ type Program struct {
 	Statements []Statement
 }
 
+func (p *Program) Pos() mtoken.Position {
+	for _, s := range p.Statements {
+		if !IsNil(s) { // 構文エラーになった文は型付きのnilで入っている
+			return s.Pos()
+		}
+	}
+	return mtoken.Position{Line: 1, Column: 1}
+}
+
 func (p *Program) TokenLiteral() string {
 	if len(p.Statements) > 0 {
 		return p.Statements[0].TokenLiteral()
@@ -96,7 +108,8 @@ type LetStatement struct {
 	Value Expression
 }
 
-func (ls *LetStatement) statementNode() {}
+func (ls *LetStatement) statementNode()       {}
+func (ls *LetStatement) Pos() mtoken.Position { return ls.Token.Pos }
 
 func (ls *LetStatement) TokenLiteral() string {
 	return ls.Token.Literal
@@ -105,7 +118,7 @@ func (ls *LetStatement) TokenLiteral() string {
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(ls.TokenLiteral() + "")
+	out.WriteString(ls.TokenLiteral() + " ")
 	out.WriteString(ls.Name.String())
 	out.WriteString(" = ")
 
@@ -123,6 +136,7 @@ type ReturnStatement struct {
 }
 
 func (rs *ReturnStatement) statementNode()       {}
+func (rs *ReturnStatement) Pos() mtoken.Position { return rs.Token.Pos }
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
@@ -144,6 +158,7 @@ type Identifier struct {
 }
 
 func (i *Identifier) expressionNode()      {}
+func (i *Identifier) Pos() mtoken.Position { return i.Token.Pos }
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) String() string       { return i.Value }
 
@@ -153,6 +168,7 @@ type IntegerLiteral struct {
 }
 
 func (il *IntegerLiteral) expressionNode()      {}
+func (il *IntegerLiteral) Pos() mtoken.Position { return il.Token.Pos }
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
@@ -163,7 +179,13 @@ type InfixExpression struct {
 	Right    Expression
 }
 
-func (ie *InfixExpression) expressionNode()      {}
+func (ie *InfixExpression) expressionNode() {}
+func (ie *InfixExpression) Pos() mtoken.Position {
+	if ie.Left == nil {
+		return ie.Token.Pos
+	}
+	return ie.Left.Pos()
+}
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
@@ -176,3 +198,46 @@ func (ie *InfixExpression) String() string {
 
 	return out.String()
 }
+
+// ImportStatement import "path/to/mod.mk" as m;
+type ImportStatement struct {
+	Token mtoken.Token // IMPORT トークン
+	Path  string       // 読み込むファイルのパス(importするファイルからの相対パス)
+	Alias *Identifier  // モジュールを束縛する名前
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) Pos() mtoken.Position { return is.Token.Pos }
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(`"` + is.Path + `"`)
+	out.WriteString(" as ")
+	if is.Alias != nil {
+		out.WriteString(is.Alias.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// MemberExpression モジュールのメンバーアクセス m.name
+type MemberExpression struct {
+	Token  mtoken.Token // DOT トークン
+	Object Expression
+	Member *Identifier
+}
+
+func (me *MemberExpression) expressionNode() {}
+func (me *MemberExpression) Pos() mtoken.Position {
+	if me.Object == nil {
+		return me.Token.Pos
+	}
+	return me.Object.Pos()
+}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	return me.Object.String() + "." + me.Member.String()
+}
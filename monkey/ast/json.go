@@ -0,0 +1,346 @@
+package ast
+
+// ASTのJSON表現。
+// * 全てのノードは型名を"kind"に持つ。インターフェイスのフィールドはこれを見て具体的な型に戻す
+// * トークンは位置ごと"token"に入れる。Programはトークンを持たないので"pos"だけ書き、読むときは無視する
+// * nilのノードはnullになる。構文エラーで入った型付きのnilの文は、読むとnilのStatementになる
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+var kinds = map[string]func() Node{
+	"Program":             func() Node { return &Program{} },
+	"LetStatement":        func() Node { return &LetStatement{} },
+	"ReturnStatement":     func() Node { return &ReturnStatement{} },
+	"ExpressionStatement": func() Node { return &ExpressionStatement{} },
+	"ImportStatement":     func() Node { return &ImportStatement{} },
+	"Identifier":          func() Node { return &Identifier{} },
+	"IntegerLiteral":      func() Node { return &IntegerLiteral{} },
+	"PrefixExpression":    func() Node { return &PrefixExpression{} },
+	"InfixExpression":     func() Node { return &InfixExpression{} },
+	"MemberExpression":    func() Node { return &MemberExpression{} },
+}
+
+// MarshalJSON nodeをJSONにする
+func MarshalJSON(node Node) ([]byte, error) {
+	return json.Marshal(node)
+}
+
+// UnmarshalJSON "kind"に応じたノードを作ってdataを読み込む
+func UnmarshalJSON(data []byte) (Node, error) {
+	var head struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, err
+	}
+	newNode, ok := kinds[head.Kind]
+	if !ok {
+		return nil, fmt.Errorf("ast: unknown node kind %q", head.Kind)
+	}
+	node := newNode()
+	if err := json.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
+
+func unmarshalStatement(data json.RawMessage) (Statement, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	node, err := UnmarshalJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	stmt, ok := node.(Statement)
+	if !ok {
+		return nil, fmt.Errorf("ast: %T is not a statement", node)
+	}
+	return stmt, nil
+}
+
+func unmarshalExpression(data json.RawMessage) (Expression, error) {
+	if isNull(data) {
+		return nil, nil
+	}
+	node, err := UnmarshalJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	expr, ok := node.(Expression)
+	if !ok {
+		return nil, fmt.Errorf("ast: %T is not an expression", node)
+	}
+	return expr, nil
+}
+
+// unmarshalKind dataをvに読み込み、"kind"がkindであることを確かめる
+func unmarshalKind(data []byte, kind string, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	var head struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return err
+	}
+	if head.Kind != kind {
+		return fmt.Errorf("ast: expected kind %q, got %q", kind, head.Kind)
+	}
+	return nil
+}
+
+func (p *Program) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind       string          `json:"kind"`
+		Pos        mtoken.Position `json:"pos"`
+		Statements []Statement     `json:"statements"`
+	}{"Program", p.Pos(), p.Statements})
+}
+
+func (p *Program) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Statements []json.RawMessage `json:"statements"`
+	}
+	if err := unmarshalKind(data, "Program", &v); err != nil {
+		return err
+	}
+	p.Statements = []Statement{}
+	for _, s := range v.Statements {
+		stmt, err := unmarshalStatement(s)
+		if err != nil {
+			return err
+		}
+		p.Statements = append(p.Statements, stmt)
+	}
+	return nil
+}
+
+func (ls *LetStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string       `json:"kind"`
+		Token mtoken.Token `json:"token"`
+		Name  *Identifier  `json:"name"`
+		Value Expression   `json:"value"`
+	}{"LetStatement", ls.Token, ls.Name, ls.Value})
+}
+
+func (ls *LetStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token mtoken.Token    `json:"token"`
+		Name  *Identifier     `json:"name"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := unmarshalKind(data, "LetStatement", &v); err != nil {
+		return err
+	}
+	value, err := unmarshalExpression(v.Value)
+	if err != nil {
+		return err
+	}
+	*ls = LetStatement{Token: v.Token, Name: v.Name, Value: value}
+	return nil
+}
+
+func (rs *ReturnStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind        string       `json:"kind"`
+		Token       mtoken.Token `json:"token"`
+		ReturnValue Expression   `json:"returnValue"`
+	}{"ReturnStatement", rs.Token, rs.ReturnValue})
+}
+
+func (rs *ReturnStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token       mtoken.Token    `json:"token"`
+		ReturnValue json.RawMessage `json:"returnValue"`
+	}
+	if err := unmarshalKind(data, "ReturnStatement", &v); err != nil {
+		return err
+	}
+	value, err := unmarshalExpression(v.ReturnValue)
+	if err != nil {
+		return err
+	}
+	*rs = ReturnStatement{Token: v.Token, ReturnValue: value}
+	return nil
+}
+
+func (es *ExpressionStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind       string       `json:"kind"`
+		Token      mtoken.Token `json:"token"`
+		Expression Expression   `json:"expression"`
+	}{"ExpressionStatement", es.Token, es.Expression})
+}
+
+func (es *ExpressionStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token      mtoken.Token    `json:"token"`
+		Expression json.RawMessage `json:"expression"`
+	}
+	if err := unmarshalKind(data, "ExpressionStatement", &v); err != nil {
+		return err
+	}
+	expr, err := unmarshalExpression(v.Expression)
+	if err != nil {
+		return err
+	}
+	*es = ExpressionStatement{Token: v.Token, Expression: expr}
+	return nil
+}
+
+func (is *ImportStatement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string       `json:"kind"`
+		Token mtoken.Token `json:"token"`
+		Path  string       `json:"path"`
+		Alias *Identifier  `json:"alias"`
+	}{"ImportStatement", is.Token, is.Path, is.Alias})
+}
+
+func (is *ImportStatement) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token mtoken.Token `json:"token"`
+		Path  string       `json:"path"`
+		Alias *Identifier  `json:"alias"`
+	}
+	if err := unmarshalKind(data, "ImportStatement", &v); err != nil {
+		return err
+	}
+	*is = ImportStatement{Token: v.Token, Path: v.Path, Alias: v.Alias}
+	return nil
+}
+
+func (i *Identifier) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string       `json:"kind"`
+		Token mtoken.Token `json:"token"`
+		Value string       `json:"value"`
+	}{"Identifier", i.Token, i.Value})
+}
+
+func (i *Identifier) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token mtoken.Token `json:"token"`
+		Value string       `json:"value"`
+	}
+	if err := unmarshalKind(data, "Identifier", &v); err != nil {
+		return err
+	}
+	*i = Identifier{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+func (il *IntegerLiteral) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind  string       `json:"kind"`
+		Token mtoken.Token `json:"token"`
+		Value int64        `json:"value"`
+	}{"IntegerLiteral", il.Token, il.Value})
+}
+
+func (il *IntegerLiteral) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token mtoken.Token `json:"token"`
+		Value int64        `json:"value"`
+	}
+	if err := unmarshalKind(data, "IntegerLiteral", &v); err != nil {
+		return err
+	}
+	*il = IntegerLiteral{Token: v.Token, Value: v.Value}
+	return nil
+}
+
+func (pe *PrefixExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind     string       `json:"kind"`
+		Token    mtoken.Token `json:"token"`
+		Operator string       `json:"operator"`
+		Right    Expression   `json:"right"`
+	}{"PrefixExpression", pe.Token, pe.Operator, pe.Right})
+}
+
+func (pe *PrefixExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token    mtoken.Token    `json:"token"`
+		Operator string          `json:"operator"`
+		Right    json.RawMessage `json:"right"`
+	}
+	if err := unmarshalKind(data, "PrefixExpression", &v); err != nil {
+		return err
+	}
+	right, err := unmarshalExpression(v.Right)
+	if err != nil {
+		return err
+	}
+	*pe = PrefixExpression{Token: v.Token, Operator: v.Operator, Right: right}
+	return nil
+}
+
+func (ie *InfixExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind     string       `json:"kind"`
+		Token    mtoken.Token `json:"token"`
+		Left     Expression   `json:"left"`
+		Operator string       `json:"operator"`
+		Right    Expression   `json:"right"`
+	}{"InfixExpression", ie.Token, ie.Left, ie.Operator, ie.Right})
+}
+
+func (ie *InfixExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token    mtoken.Token    `json:"token"`
+		Left     json.RawMessage `json:"left"`
+		Operator string          `json:"operator"`
+		Right    json.RawMessage `json:"right"`
+	}
+	if err := unmarshalKind(data, "InfixExpression", &v); err != nil {
+		return err
+	}
+	left, err := unmarshalExpression(v.Left)
+	if err != nil {
+		return err
+	}
+	right, err := unmarshalExpression(v.Right)
+	if err != nil {
+		return err
+	}
+	*ie = InfixExpression{Token: v.Token, Left: left, Operator: v.Operator, Right: right}
+	return nil
+}
+
+func (me *MemberExpression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Kind   string       `json:"kind"`
+		Token  mtoken.Token `json:"token"`
+		Object Expression   `json:"object"`
+		Member *Identifier  `json:"member"`
+	}{"MemberExpression", me.Token, me.Object, me.Member})
+}
+
+func (me *MemberExpression) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Token  mtoken.Token    `json:"token"`
+		Object json.RawMessage `json:"object"`
+		Member *Identifier     `json:"member"`
+	}
+	if err := unmarshalKind(data, "MemberExpression", &v); err != nil {
+		return err
+	}
+	object, err := unmarshalExpression(v.Object)
+	if err != nil {
+		return err
+	}
+	*me = MemberExpression{Token: v.Token, Object: object, Member: v.Member}
+	return nil
+}
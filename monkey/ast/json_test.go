@@ -0,0 +1,112 @@
+package ast_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/parser"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+func TestJSONGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.mk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := parser.NewParser(lexer.NewLexer(string(src)))
+		program := p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%s: parser errors: %v", file, p.Errors())
+		}
+
+		data, err := json.MarshalIndent(program, "", "  ")
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		data = append(data, '\n')
+
+		golden := strings.TrimSuffix(file, ".mk") + ".json"
+		if *update {
+			if err := os.WriteFile(golden, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		want, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want) {
+			t.Errorf("%s: json wrong.\ngot=%s\nwant=%s", file, data, want)
+		}
+
+		node, err := ast.UnmarshalJSON(want)
+		if err != nil {
+			t.Fatalf("%s: %v", golden, err)
+		}
+		if !reflect.DeepEqual(node, program) {
+			t.Errorf("%s: round-trip wrong. got=%q, want=%q", golden, node.String(), program.String())
+		}
+	}
+}
+
+func TestJSONNode(t *testing.T) {
+	p := parser.NewParser(lexer.NewLexer("return -x.y + 1;"))
+	program := p.ParseProgram()
+	stmt := program.Statements[0].(*ast.ReturnStatement)
+
+	data, err := ast.MarshalJSON(stmt.ReturnValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := ast.UnmarshalJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(node, stmt.ReturnValue) {
+		t.Errorf("round-trip wrong. got=%q, want=%q", node.String(), stmt.ReturnValue.String())
+	}
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"kind":"Function"}`, `ast: unknown node kind "Function"`},
+		{`{"statements":[]}`, `ast: unknown node kind ""`},
+		{
+			`{"kind":"Program","statements":[{"kind":"Identifier","value":"x"}]}`,
+			"ast: *ast.Identifier is not a statement",
+		},
+		{
+			`{"kind":"ReturnStatement","returnValue":{"kind":"ReturnStatement"}}`,
+			"ast: *ast.ReturnStatement is not an expression",
+		},
+		{
+			`{"kind":"LetStatement","name":{"kind":"IntegerLiteral"}}`,
+			`ast: expected kind "Identifier", got "IntegerLiteral"`,
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := ast.UnmarshalJSON([]byte(tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s: error wrong. got=%v, want=%q", tt.input, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+-a * b + c / d;
+!x == y;
+x < 10 != y > 2
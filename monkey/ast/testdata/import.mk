@@ -0,0 +1,2 @@
+import "lib/math.mk" as m;
+let area = m.pi * r * r;
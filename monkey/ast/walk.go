@@ -0,0 +1,44 @@
+package ast
+
+import "reflect"
+
+// Inspect go/astのInspectと同じく、nodeを深さ優先で辿りながらfを呼び出す。
+// fがfalseを返した場合、そのノードの子は辿らない。
+func Inspect(node Node, f func(Node) bool) {
+	if IsNil(node) || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		for _, s := range n.Statements {
+			Inspect(s, f)
+		}
+	case *LetStatement:
+		Inspect(n.Name, f)
+		Inspect(n.Value, f)
+	case *ReturnStatement:
+		Inspect(n.ReturnValue, f)
+	case *ExpressionStatement:
+		Inspect(n.Expression, f)
+	case *ImportStatement:
+		Inspect(n.Alias, f)
+	case *PrefixExpression:
+		Inspect(n.Right, f)
+	case *InfixExpression:
+		Inspect(n.Left, f)
+		Inspect(n.Right, f)
+	case *MemberExpression:
+		Inspect(n.Object, f)
+		Inspect(n.Member, f)
+	}
+}
+
+// IsNil nodeがnilかどうか。構文解析に失敗した文は型付きのnilポインタのままインターフェイスに入るので、それもnilとみなす
+func IsNil(node Node) bool {
+	if node == nil {
+		return true
+	}
+	v := reflect.ValueOf(node)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
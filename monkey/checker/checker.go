@@ -0,0 +1,50 @@
+package checker
+
+// 構文解析は通るが意味的に誤っているプログラムを検出する。
+// * 束縛されていない識別子の参照
+// * 同じ名前の再束縛
+// 関数リテラルはまだ構文解析できないので、スコープはトップレベルの1つだけ。
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+// Error 位置情報付きの意味エラー
+type Error struct {
+	Pos mtoken.Position
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+func Check(program *ast.Program) []*Error {
+	var errors []*Error
+	bindings, refs := Resolve(program)
+
+	bound := make(map[string]bool)
+	for _, b := range bindings {
+		if bound[b.Name.Value] {
+			errors = append(errors, errorf(b.Name.Token.Pos, "%s redeclared", b.Name.Value))
+		}
+		bound[b.Name.Value] = true
+	}
+	for _, r := range refs {
+		if r.Binding == nil {
+			errors = append(errors, errorf(r.Ident.Token.Pos, "undefined: %s", r.Ident.Value))
+		}
+	}
+
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].Pos.Offset < errors[j].Pos.Offset
+	})
+	return errors
+}
+
+func errorf(pos mtoken.Position, format string, a ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, a...)}
+}
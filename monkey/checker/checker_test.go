@@ -0,0 +1,41 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/parser"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; let y = x + 1; -y;", nil},
+		{`import "a.mk" as a; a.Anything;`, nil},
+		{"let x = x;", []string{"1:9: undefined: x"}},
+		{"y;\nreturn z;", []string{"1:1: undefined: y", "2:8: undefined: z"}},
+		{"let x = 1;\nlet x = 2;", []string{"2:5: x redeclared"}},
+		{`let m = 1; import "m.mk" as m; m.x; n.x;`, []string{"1:29: m redeclared", "1:37: undefined: n"}},
+	}
+
+	for _, tt := range tests {
+		p := parser.NewParser(lexer.NewLexer(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			t.Fatalf("%q: parser errors: %v", tt.input, p.Errors())
+		}
+
+		errors := Check(program)
+		if len(errors) != len(tt.expected) {
+			t.Errorf("%q: expected %d errors, got=%v", tt.input, len(tt.expected), errors)
+			continue
+		}
+		for i, e := range errors {
+			if e.Error() != tt.expected[i] {
+				t.Errorf("%q: errors[%d] = %q, want %q", tt.input, i, e.Error(), tt.expected[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"sort"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+)
+
+// Binding let文かimport文で束縛された名前
+type Binding struct {
+	Name *ast.Identifier
+	Stmt ast.Statement
+}
+
+// Reference ソース上に現れた識別子と、それが指す束縛。束縛の名前自身も含む
+type Reference struct {
+	Ident   *ast.Identifier
+	Binding *Binding // 束縛されていない識別子ならnil
+}
+
+// Resolve 識別子がどの束縛を指しているかを文の順に解決する。
+// Referenceはソース上の位置の順に並ぶ。m.nameのnameは別のファイルの束縛なので含めない。
+func Resolve(program *ast.Program) ([]*Binding, []*Reference) {
+	var bindings []*Binding
+	var refs []*Reference
+	scope := make(map[string]*Binding)
+
+	refer := func(e ast.Expression) {
+		ast.Inspect(e, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.MemberExpression:
+				ast.Inspect(n.Object, func(n ast.Node) bool {
+					if ident, ok := n.(*ast.Identifier); ok {
+						refs = append(refs, &Reference{Ident: ident, Binding: scope[ident.Value]})
+					}
+					return true
+				})
+				return false
+			case *ast.Identifier:
+				refs = append(refs, &Reference{Ident: n, Binding: scope[n.Value]})
+			}
+			return true
+		})
+	}
+	bind := func(name *ast.Identifier, stmt ast.Statement) {
+		b := &Binding{Name: name, Stmt: stmt}
+		bindings = append(bindings, b)
+		refs = append(refs, &Reference{Ident: name, Binding: b})
+		scope[name.Value] = b
+	}
+
+	for _, s := range program.Statements {
+		switch stmt := s.(type) {
+		case *ast.LetStatement:
+			if stmt == nil {
+				continue
+			}
+			// let x = x; の右辺のxはまだ束縛されていない
+			refer(stmt.Value)
+			bind(stmt.Name, stmt)
+		case *ast.ImportStatement:
+			if stmt == nil {
+				continue
+			}
+			bind(stmt.Alias, stmt)
+		case *ast.ReturnStatement:
+			if stmt == nil {
+				continue
+			}
+			refer(stmt.ReturnValue)
+		case *ast.ExpressionStatement:
+			if stmt == nil {
+				continue
+			}
+			refer(stmt.Expression)
+		}
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Ident.Token.Pos.Offset < refs[j].Ident.Token.Pos.Offset
+	})
+	return bindings, refs
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/checker"
+	"github.com/tMinamiii/various-parser/monkey/dump"
+	"github.com/tMinamiii/various-parser/monkey/format"
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/lsp"
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+	"github.com/tMinamiii/various-parser/monkey/parser"
+)
+
+type command struct {
+	summary string
+	run     func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+var commands = map[string]command{
+	"run":   {"parse, check and run a program", runRun},
+	"lex":   {"print the tokens of a program", runLex},
+	"parse": {"print each parsed statement", runParse},
+	"ast":   {"print the syntax tree as a tree, DOT or S-expressions", runAST},
+	"fmt":   {"format a program", runFmt},
+	"check": {"report syntax and semantic errors", runCheck},
+	"lsp":   {"start the language server on stdin and stdout", runLSP},
+}
+
+// programError 入力のプログラムにあったエラー。名前の後ろに位置付きで1行ずつ出す
+type programError struct {
+	name   string
+	errors []error
+}
+
+func (e *programError) Error() string {
+	return fmt.Sprintf("%s:%s", e.name, e.errors[0])
+}
+
+// errNoEvaluator 評価器はまだ無いので、runは検査までしかできない
+var errNoEvaluator = errors.New("cannot run: the evaluator is not implemented yet")
+
+// readInput ファイル名が無いか「-」なら標準入力を読む
+func readInput(fs *flag.FlagSet, stdin io.Reader) (name string, src string, err error) {
+	if fs.NArg() > 1 {
+		return "", "", fmt.Errorf("too many arguments")
+	}
+	name = fs.Arg(0)
+	var b []byte
+	if name == "" || name == "-" {
+		name = "<stdin>"
+		b, err = io.ReadAll(stdin)
+	} else {
+		b, err = os.ReadFile(name)
+	}
+	return name, string(b), err
+}
+
+// parse 構文エラーがあればprogramErrorを返す
+func parse(name string, src string) (*ast.Program, error) {
+	p := parser.NewParser(lexer.NewLexer(src))
+	program := p.ParseProgram()
+	if errs := p.ParseErrors(); len(errs) > 0 {
+		perr := &programError{name: name}
+		for _, e := range errs {
+			perr.errors = append(perr.errors, e)
+		}
+		return program, perr
+	}
+	return program, nil
+}
+
+// check 構文エラーと意味エラーをまとめて確かめる
+func check(name string, src string) (*ast.Program, error) {
+	program, err := parse(name, src)
+	if err != nil {
+		return nil, err
+	}
+	if errs := checker.Check(program); len(errs) > 0 {
+		perr := &programError{name: name}
+		for _, e := range errs {
+			perr.errors = append(perr.errors, e)
+		}
+		return nil, perr
+	}
+	return program, nil
+}
+
+// runRun monkey run [file.mk]
+func runRun(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, src, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+	if _, err := check(name, src); err != nil {
+		return err
+	}
+	return errNoEvaluator
+}
+
+// runLex monkey lex [file.mk]
+// 1行に1トークンを「位置 タイプ リテラル」で出す。不正な文字があればエラーにする
+func runLex(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("lex", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, src, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+
+	perr := &programError{name: name}
+	l := lexer.NewLexer(src)
+	for tok := l.NextToken(); tok.Type != mtoken.EOF; tok = l.NextToken() {
+		fmt.Fprintf(stdout, "%s\t%s\t%q\n", tok.Pos, tok.Type, tok.Literal)
+		if tok.Type == mtoken.ILLEGAL {
+			perr.errors = append(perr.errors, fmt.Errorf("%s: %s", tok.Pos, lexer.IllegalMessage(tok)))
+		}
+	}
+	if len(perr.errors) > 0 {
+		return perr
+	}
+	return nil
+}
+
+// runParse monkey parse [file.mk]
+// 文ごとにString()を1行で出す。括弧で演算子の結合が分かる
+func runParse(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("parse", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, src, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+	program, err := parse(name, src)
+	if err != nil {
+		return err
+	}
+	for _, s := range program.Statements {
+		fmt.Fprintln(stdout, s.String())
+	}
+	return nil
+}
+
+// runAST monkey ast [--format=tree|dot|sexpr] [file.mk]
+// 構文エラーがあっても解析できたところまでは出力する
+func runAST(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("ast", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	formatName := fs.String("format", "tree", "output format: tree, dot or sexpr")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	write, ok := dump.Formats[*formatName]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *formatName)
+	}
+	name, src, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+
+	program, perr := parse(name, src)
+	if err := write(stdout, program); err != nil {
+		return err
+	}
+	return perr
+}
+
+// runFmt monkey fmt [-w] [file.mk]
+// -wなら結果を標準出力ではなく元のファイルに書く
+func runFmt(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	write := fs.Bool("w", false, "write the result to the file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, src, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+	if *write && name == "<stdin>" {
+		return fmt.Errorf("-w needs a file")
+	}
+
+	// 位置付きのエラーを出すために先に構文解析する
+	if _, err := parse(name, src); err != nil {
+		return err
+	}
+	formatted, err := format.Source(src)
+	if err != nil {
+		return err
+	}
+	if *write {
+		return os.WriteFile(name, []byte(formatted), 0644)
+	}
+	_, err = io.WriteString(stdout, formatted)
+	return err
+}
+
+// runCheck monkey check [file.mk]
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	name, src, err := readInput(fs, stdin)
+	if err != nil {
+		return err
+	}
+	_, err = check(name, src)
+	return err
+}
+
+// runLSP monkey lsp
+// 標準出力はLSPのメッセージ専用なので挨拶は出さない
+func runLSP(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) > 0 {
+		return fmt.Errorf("too many arguments")
+	}
+	return lsp.NewServer(stdin, stdout).Serve()
+}
@@ -0,0 +1,148 @@
+package cst
+
+// CSTのノードとASTのノードの対応付け。
+// 文はノードの種類と最初のトークンの位置で、文の中は同じ形の子を順に辿って対応させる。
+// 構文エラーの周辺ではCSTとASTの形が食い違うので、対応が取れないノードもある。
+import (
+	"github.com/tMinamiii/various-parser/monkey/ast"
+)
+
+type nodeKey struct {
+	kind   NodeKind
+	offset int
+	width  int
+}
+
+func keyOf(n *Node) nodeKey {
+	return nodeKey{kind: n.Kind(), offset: n.Offset, width: n.Green.Width()}
+}
+
+// ASTNode nに対応するASTのノード。無ければnil
+func (t *Tree) ASTNode(n *Node) ast.Node {
+	return t.nodes[keyOf(n)]
+}
+
+// NodeOf ASTのノードに対応するCSTのノード。無ければnil
+func (t *Tree) NodeOf(target ast.Node) *Node {
+	key, ok := t.keys[target]
+	if !ok {
+		return nil
+	}
+	return t.Root.find(key)
+}
+
+func (n *Node) find(key nodeKey) *Node {
+	if keyOf(n) == key {
+		return n
+	}
+	for _, c := range n.ChildNodes() {
+		if c.Start() <= key.offset && key.offset+key.width <= c.End() {
+			if found := c.find(key); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// significantStart 先頭の空白とコメントを除いた開始位置
+func (n *Node) significantStart() int {
+	for _, tok := range n.Tokens() {
+		if !tok.IsTrivia() {
+			return tok.Start()
+		}
+	}
+	return n.End()
+}
+
+func (t *Tree) index() {
+	t.nodes = make(map[nodeKey]ast.Node)
+	t.keys = make(map[ast.Node]nodeKey)
+
+	stmts := make(map[int]*Node)
+	for _, n := range t.Root.ChildNodes() {
+		stmts[n.significantStart()] = n
+	}
+
+	for _, s := range t.Program.Statements {
+		if ast.IsNil(s) { // 構文エラーになった文は型付きのnilで入っている
+			continue
+		}
+		if n, ok := stmts[s.Pos().Offset]; ok {
+			t.match(n, s)
+		}
+	}
+}
+
+// match nとnodeの種類が一致すれば対応を記録し、子を順に対応させる
+func (t *Tree) match(n *Node, node ast.Node) {
+	if n == nil || node == nil || kindOf(node) != n.Kind() {
+		return
+	}
+	t.nodes[keyOf(n)] = node
+	t.keys[node] = keyOf(n)
+
+	children := n.ChildNodes()
+	child := func(i int) *Node {
+		if 0 <= i && i < len(children) {
+			return children[i]
+		}
+		return nil
+	}
+
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		t.match(child(0), node.Name)
+		if node.Value != nil {
+			t.match(child(1), node.Value)
+		}
+	case *ast.ReturnStatement:
+		if node.ReturnValue != nil {
+			t.match(child(0), node.ReturnValue)
+		}
+	case *ast.ImportStatement:
+		t.match(child(len(children)-1), node.Alias)
+	case *ast.ExpressionStatement:
+		if node.Expression != nil {
+			t.match(child(0), node.Expression)
+		}
+	case *ast.PrefixExpression:
+		if node.Right != nil {
+			t.match(child(0), node.Right)
+		}
+	case *ast.InfixExpression:
+		if node.Left != nil && node.Right != nil {
+			t.match(child(0), node.Left)
+			t.match(child(1), node.Right)
+		}
+	case *ast.MemberExpression:
+		if node.Object != nil && node.Member != nil {
+			t.match(child(0), node.Object)
+			t.match(child(1), node.Member)
+		}
+	}
+}
+
+func kindOf(node ast.Node) NodeKind {
+	switch node.(type) {
+	case *ast.LetStatement:
+		return LetStatement
+	case *ast.ReturnStatement:
+		return ReturnStatement
+	case *ast.ImportStatement:
+		return ImportStatement
+	case *ast.ExpressionStatement:
+		return ExpressionStatement
+	case *ast.PrefixExpression:
+		return PrefixExpression
+	case *ast.InfixExpression:
+		return InfixExpression
+	case *ast.MemberExpression:
+		return MemberExpression
+	case *ast.Identifier:
+		return Identifier
+	case *ast.IntegerLiteral:
+		return IntegerLiteral
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package cst
+
+import (
+	"testing"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+)
+
+func TestRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"  \n// only trivia\n",
+		"let x = 5;",
+		"let  x=5 // five\n;return -x*  (y)",
+		`import "lib.mk"as m ; m.A.B + !c;`,
+		"let = ;",
+		"let x",
+		"import ;",
+		"}{)(,,, @ 日本語",
+		"a +",
+		"\"unterminated",
+		"a\x00b",
+		"x / / y",
+	}
+
+	for _, input := range inputs {
+		tree := Parse(input)
+		if got := tree.Root.Text(); got != input {
+			t.Errorf("Parse(%q).Root.Text() = %q", input, got)
+		}
+		if tree.Root.End() != len(input) {
+			t.Errorf("Parse(%q).Root.End() = %d, want %d", input, tree.Root.End(), len(input))
+		}
+	}
+}
+
+func TestDump(t *testing.T) {
+	tree := Parse("let a = -b.c * 2; // x\nlet = ;")
+	want := `SourceFile@0..30
+  LetStatement@0..17
+    LET@0..3 "let"
+    WHITESPACE@3..4 " "
+    Identifier@4..5
+      IDENT@4..5 "a"
+    WHITESPACE@5..6 " "
+    =@6..7 "="
+    WHITESPACE@7..8 " "
+    InfixExpression@8..16
+      PrefixExpression@8..12
+        -@8..9 "-"
+        MemberExpression@9..12
+          Identifier@9..10
+            IDENT@9..10 "b"
+          .@10..11 "."
+          Identifier@11..12
+            IDENT@11..12 "c"
+      WHITESPACE@12..13 " "
+      *@13..14 "*"
+      WHITESPACE@14..15 " "
+      IntegerLiteral@15..16
+        INT@15..16 "2"
+    ;@16..17 ";"
+  WHITESPACE@17..18 " "
+  COMMENT@18..22 "// x"
+  WHITESPACE@22..23 "\n"
+  LetStatement@23..30
+    LET@23..26 "let"
+    Missing@26..26
+    WHITESPACE@26..27 " "
+    =@27..28 "="
+    WHITESPACE@28..29 " "
+    Missing@29..29
+    ;@29..30 ";"
+`
+	if got := tree.Root.Dump(); got != want {
+		t.Errorf("Dump() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestErrorNodes(t *testing.T) {
+	tree := Parse("a ) b")
+	var kinds []NodeKind
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		kinds = append(kinds, n.Kind())
+		for _, c := range n.ChildNodes() {
+			walk(c)
+		}
+	}
+	walk(tree.Root)
+
+	want := []NodeKind{SourceFile, ExpressionStatement, Identifier, ExpressionStatement, Error, ExpressionStatement, Identifier}
+	if len(kinds) != len(want) {
+		t.Fatalf("kinds = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("kinds[%d] = %s, want %s", i, kinds[i], want[i])
+		}
+	}
+}
+
+func TestASTMapping(t *testing.T) {
+	src := "let a = 1;\n// c\nlet b = a + m.x;\n"
+	tree := Parse(src)
+	if len(tree.Errors) > 0 {
+		t.Fatalf("parser errors: %v", tree.Errors)
+	}
+
+	stmts := tree.Root.ChildNodes()
+	if len(stmts) != 2 {
+		t.Fatalf("expected 2 statements, got %d", len(stmts))
+	}
+
+	let, ok := tree.ASTNode(stmts[1]).(*ast.LetStatement)
+	if !ok || let != tree.Program.Statements[1] {
+		t.Fatalf("ASTNode(stmts[1]) = %T, want the second *ast.LetStatement", tree.ASTNode(stmts[1]))
+	}
+
+	// 全てのASTノードに対応するCSTノードがあり、その範囲は元のテキストと一致する
+	ast.Inspect(tree.Program, func(n ast.Node) bool {
+		if _, ok := n.(*ast.Program); ok {
+			return true
+		}
+		node := tree.NodeOf(n)
+		if node == nil {
+			t.Errorf("NodeOf(%T %q) = nil", n, n.String())
+			return true
+		}
+		if tree.ASTNode(node) != n {
+			t.Errorf("ASTNode(NodeOf(%q)) does not round trip", n.String())
+		}
+		if node.Start() != n.Pos().Offset {
+			t.Errorf("NodeOf(%q).Start() = %d, want %d", n.String(), node.Start(), n.Pos().Offset)
+		}
+		return true
+	})
+
+	member := tree.NodeOf(let.Value.(*ast.InfixExpression).Right)
+	if member.Kind() != MemberExpression || member.Text() != "m.x" {
+		t.Errorf("member = %s %q", member.Kind(), member.Text())
+	}
+}
+
+func TestGreenWidth(t *testing.T) {
+	g := Build("let a = 1;\nlet b = 2;")
+	if g.Width() != len("let a = 1;\nlet b = 2;") {
+		t.Errorf("Width() = %d", g.Width())
+	}
+	var sum int
+	for _, c := range g.Children {
+		sum += c.Width()
+	}
+	if sum != g.Width() {
+		t.Errorf("sum of children = %d, want %d", sum, g.Width())
+	}
+}
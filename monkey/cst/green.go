@@ -0,0 +1,106 @@
+package cst
+
+// 具象構文木(CST)はgreen treeとred treeの2層からなる。
+// * green tree 種類とテキスト(幅)だけを持つ不変の木。位置を持たないので、
+//   同じ形の部分木は編集の前後で使い回せる
+// * red tree green treeの上に親と絶対位置を載せた薄いビュー。必要になった時に作る
+import (
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+// NodeKind 内部ノードの種類
+type NodeKind string
+
+const (
+	SourceFile          NodeKind = "SourceFile"
+	LetStatement        NodeKind = "LetStatement"
+	ReturnStatement     NodeKind = "ReturnStatement"
+	ImportStatement     NodeKind = "ImportStatement"
+	ExpressionStatement NodeKind = "ExpressionStatement"
+	PrefixExpression    NodeKind = "PrefixExpression"
+	InfixExpression     NodeKind = "InfixExpression"
+	MemberExpression    NodeKind = "MemberExpression"
+	Identifier          NodeKind = "Identifier"
+	IntegerLiteral      NodeKind = "IntegerLiteral"
+
+	// Error 文法に合わないトークンをまとめたもの
+	Error NodeKind = "Error"
+	// Missing 本来あるはずのトークンや式が無かった場所。幅は0
+	Missing NodeKind = "Missing"
+)
+
+// GreenToken 空白やコメントも含めた1トークン
+type GreenToken struct {
+	Type mtoken.TokenType
+	Text string
+}
+
+func (t *GreenToken) Width() int { return len(t.Text) }
+
+// GreenNode 子はGreenNodeかGreenTokenのどちらか
+type GreenNode struct {
+	Kind     NodeKind
+	Children []GreenElement
+	width    int
+}
+
+func (n *GreenNode) Width() int { return n.width }
+
+type GreenElement interface {
+	Width() int
+}
+
+func NewGreenNode(kind NodeKind, children []GreenElement) *GreenNode {
+	n := &GreenNode{Kind: kind, Children: children}
+	for _, c := range children {
+		n.width += c.Width()
+	}
+	return n
+}
+
+// builder 開いているノードのスタックを持ち、子を積みながらgreen treeを組み立てる
+type builder struct {
+	stack []*openNode
+}
+
+type openNode struct {
+	kind     NodeKind
+	children []GreenElement
+}
+
+// checkpoint 後から「ここから先の子を新しいノードで包む」ための目印。
+// 中置演算子は左辺を読み終えてから初めてノードの種類が分かるので必要になる。
+type checkpoint int
+
+func (b *builder) current() *openNode {
+	return b.stack[len(b.stack)-1]
+}
+
+func (b *builder) startNode(kind NodeKind) {
+	b.stack = append(b.stack, &openNode{kind: kind})
+}
+
+func (b *builder) checkpoint() checkpoint {
+	return checkpoint(len(b.current().children))
+}
+
+func (b *builder) startNodeAt(cp checkpoint, kind NodeKind) {
+	parent := b.current()
+	wrapped := append([]GreenElement{}, parent.children[cp:]...)
+	parent.children = parent.children[:cp]
+	b.stack = append(b.stack, &openNode{kind: kind, children: wrapped})
+}
+
+func (b *builder) token(t mtoken.TokenType, text string) {
+	b.current().children = append(b.current().children, &GreenToken{Type: t, Text: text})
+}
+
+func (b *builder) finishNode() *GreenNode {
+	open := b.current()
+	b.stack = b.stack[:len(b.stack)-1]
+	n := NewGreenNode(open.kind, open.children)
+	if len(b.stack) > 0 {
+		b.current().children = append(b.current().children, n)
+	}
+	return n
+}
@@ -0,0 +1,182 @@
+package cst
+
+// parser.Parserと同じ文法でlexer.Tokenizeのトークン列を読み、全てのトークンをどこかのノードに収める。
+// * 空白とコメントは次のトークンと同じノードに入れる。ただしノードを開く前に出てきたものは親に残す
+// * 前置の構文解析関数が無いトークンはErrorノードに包んで1つ読み飛ばす
+// * 期待したトークンが無ければ幅0のMissingノードを置き、トークンは消費しない
+import (
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+	"github.com/tMinamiii/various-parser/monkey/parser"
+)
+
+// Tree ソースから作ったCSTと、同じソースをparser.Parserで解析したAST
+type Tree struct {
+	Root    *Node
+	Program *ast.Program
+	Errors  []string
+
+	nodes map[nodeKey]ast.Node
+	keys  map[ast.Node]nodeKey
+}
+
+// Parse srcからCSTとASTを両方作る
+func Parse(src string) *Tree {
+	p := parser.NewParser(lexer.NewLexer(src))
+	program := p.ParseProgram()
+
+	t := &Tree{
+		Root:    &Node{Green: Build(src)},
+		Program: program,
+		Errors:  p.Errors(),
+	}
+	t.index()
+	return t
+}
+
+// Build srcからgreen treeだけを作る
+func Build(src string) *GreenNode {
+	p := &cstParser{tokens: lexer.Tokenize(src)}
+	p.b.startNode(SourceFile)
+	for p.peek() != mtoken.EOF {
+		p.parseStatement()
+	}
+	p.eatTrivia()
+	return p.b.finishNode()
+}
+
+type cstParser struct {
+	tokens []lexer.Token
+	pos    int
+	b      builder
+}
+
+// peek 空白とコメントを飛ばした次のトークンのタイプ
+func (p *cstParser) peek() mtoken.TokenType {
+	for i := p.pos; i < len(p.tokens); i++ {
+		if k := p.tokens[i].Kind; k != lexer.KindWhitespace && k != lexer.KindComment {
+			return p.tokens[i].Type
+		}
+	}
+	return mtoken.EOF
+}
+
+func (p *cstParser) eatTrivia() {
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		if tok.Kind != lexer.KindWhitespace && tok.Kind != lexer.KindComment {
+			return
+		}
+		p.b.token(tok.Type, tok.Text)
+		p.pos++
+	}
+}
+
+// bump 手前の空白ごと次のトークンを今のノードに加える
+func (p *cstParser) bump() {
+	p.eatTrivia()
+	if p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+		p.b.token(tok.Type, tok.Text)
+		p.pos++
+	}
+}
+
+func (p *cstParser) missing() {
+	p.b.startNode(Missing)
+	p.b.finishNode()
+}
+
+func (p *cstParser) expect(t mtoken.TokenType) {
+	if p.peek() == t {
+		p.bump()
+		return
+	}
+	p.missing()
+}
+
+func (p *cstParser) parseStatement() {
+	p.eatTrivia()
+	switch p.peek() {
+	case mtoken.LET:
+		p.b.startNode(LetStatement)
+		p.bump()
+		p.parseName()
+		p.expect(mtoken.ASSIGN)
+		p.parseExpression(parser.LOWEST)
+	case mtoken.RETURN:
+		p.b.startNode(ReturnStatement)
+		p.bump()
+		p.parseExpression(parser.LOWEST)
+	case mtoken.IMPORT:
+		p.b.startNode(ImportStatement)
+		p.bump()
+		p.expect(mtoken.STRING)
+		p.expect(mtoken.AS)
+		p.parseName()
+	default:
+		p.b.startNode(ExpressionStatement)
+		p.parseExpression(parser.LOWEST)
+	}
+
+	if p.peek() == mtoken.SEMICOLON {
+		p.bump()
+	}
+	p.b.finishNode()
+}
+
+// parseName let文やimport文で束縛される名前
+func (p *cstParser) parseName() {
+	if p.peek() != mtoken.IDENT {
+		p.missing()
+		return
+	}
+	p.eatTrivia()
+	p.b.startNode(Identifier)
+	p.bump()
+	p.b.finishNode()
+}
+
+func (p *cstParser) parseExpression(precedence int) {
+	p.eatTrivia()
+	cp := p.b.checkpoint()
+
+	switch p.peek() {
+	case mtoken.IDENT:
+		p.b.startNode(Identifier)
+		p.bump()
+		p.b.finishNode()
+	case mtoken.INT:
+		p.b.startNode(IntegerLiteral)
+		p.bump()
+		p.b.finishNode()
+	case mtoken.BANG, mtoken.MINUS:
+		p.b.startNode(PrefixExpression)
+		p.bump()
+		p.parseExpression(parser.PREFIX)
+		p.b.finishNode()
+	case mtoken.SEMICOLON, mtoken.EOF:
+		p.missing()
+		return
+	default:
+		p.b.startNode(Error)
+		p.bump()
+		p.b.finishNode()
+		return
+	}
+
+	for p.peek() != mtoken.SEMICOLON && precedence < parser.Precedence(p.peek()) {
+		if p.peek() == mtoken.DOT {
+			p.b.startNodeAt(cp, MemberExpression)
+			p.bump()
+			p.parseName()
+		} else {
+			p.b.startNodeAt(cp, InfixExpression)
+			prec := parser.Precedence(p.peek())
+			p.bump()
+			p.parseExpression(prec)
+		}
+		p.b.finishNode()
+	}
+}
@@ -0,0 +1,139 @@
+package cst
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+// Node red treeの内部ノード。Offsetはソース先頭からのバイト位置
+type Node struct {
+	Green  *GreenNode
+	Parent *Node
+	Offset int
+}
+
+// Token red treeの葉
+type Token struct {
+	Green  *GreenToken
+	Parent *Node
+	Offset int
+}
+
+// Element NodeかTokenのどちらか
+type Element interface {
+	Start() int
+	End() int
+	Text() string
+}
+
+func (n *Node) Kind() NodeKind { return n.Green.Kind }
+func (n *Node) Start() int     { return n.Offset }
+func (n *Node) End() int       { return n.Offset + n.Green.Width() }
+
+func (t *Token) Type() mtoken.TokenType { return t.Green.Type }
+func (t *Token) Start() int             { return t.Offset }
+func (t *Token) End() int               { return t.Offset + t.Green.Width() }
+func (t *Token) Text() string           { return t.Green.Text }
+
+// IsTrivia 空白とコメントは構文上の意味を持たない
+func (t *Token) IsTrivia() bool {
+	return t.Green.Type == mtoken.WHITESPACE || t.Green.Type == mtoken.COMMENT
+}
+
+// Children 子の位置は前の兄弟の幅を足していけば求まる
+func (n *Node) Children() []Element {
+	children := make([]Element, 0, len(n.Green.Children))
+	offset := n.Offset
+	for _, c := range n.Green.Children {
+		switch c := c.(type) {
+		case *GreenNode:
+			children = append(children, &Node{Green: c, Parent: n, Offset: offset})
+		case *GreenToken:
+			children = append(children, &Token{Green: c, Parent: n, Offset: offset})
+		}
+		offset += c.Width()
+	}
+	return children
+}
+
+// ChildNodes 子のうち内部ノードだけを返す
+func (n *Node) ChildNodes() []*Node {
+	var nodes []*Node
+	for _, c := range n.Children() {
+		if c, ok := c.(*Node); ok {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// Tokens 部分木の葉を順に全て返す
+func (n *Node) Tokens() []*Token {
+	var tokens []*Token
+	for _, c := range n.Children() {
+		switch c := c.(type) {
+		case *Node:
+			tokens = append(tokens, c.Tokens()...)
+		case *Token:
+			tokens = append(tokens, c)
+		}
+	}
+	return tokens
+}
+
+// Text 部分木のテキスト。ルートならソースと完全に一致する
+func (n *Node) Text() string {
+	var out bytes.Buffer
+	writeGreen(&out, n.Green)
+	return out.String()
+}
+
+func writeGreen(out *bytes.Buffer, n *GreenNode) {
+	for _, c := range n.Children {
+		switch c := c.(type) {
+		case *GreenNode:
+			writeGreen(out, c)
+		case *GreenToken:
+			out.WriteString(c.Text)
+		}
+	}
+}
+
+// TokenAt offsetを含むトークンを返す。offsetがトークンの境界ならその後ろのトークン
+func (n *Node) TokenAt(offset int) *Token {
+	for _, c := range n.Children() {
+		if offset < c.Start() || offset >= c.End() {
+			continue
+		}
+		switch c := c.(type) {
+		case *Node:
+			return c.TokenAt(offset)
+		case *Token:
+			return c
+		}
+	}
+	return nil
+}
+
+// Dump テスト用に木をインデント付きで書き出す
+func (n *Node) Dump() string {
+	var out bytes.Buffer
+	n.dump(&out, 0)
+	return out.String()
+}
+
+func (n *Node) dump(out *bytes.Buffer, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(out, "%s%s@%d..%d\n", indent, n.Kind(), n.Start(), n.End())
+	for _, c := range n.Children() {
+		switch c := c.(type) {
+		case *Node:
+			c.dump(out, depth+1)
+		case *Token:
+			fmt.Fprintf(out, "%s  %s@%d..%d %q\n", indent, c.Type(), c.Start(), c.End(), c.Text())
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package dump
+
+// ASTの構造をそのまま見るための出力。Program.String()と違って括弧に潰さず、
+// ノードの種類、演算子やリテラルの値、位置を全て出す。
+// * Tree インデントした木
+// * Dot GraphvizのDOT
+// * Sexpr S式
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+)
+
+// Formats 名前から出力関数を引く。monkey ast --formatで使う
+var Formats = map[string]func(io.Writer, ast.Node) error{
+	"tree":  Tree,
+	"dot":   Dot,
+	"sexpr": Sexpr,
+}
+
+// field 子ノードとそれを持つフィールドの名前
+type field struct {
+	name string
+	node ast.Node
+}
+
+// describe ノードの種類、値、子ノード
+func describe(node ast.Node) (kind string, value string, fields []field) {
+	switch n := node.(type) {
+	case *ast.Program:
+		for i, s := range n.Statements {
+			fields = append(fields, field{fmt.Sprintf("Statements[%d]", i), s})
+		}
+		return "Program", "", fields
+	case *ast.LetStatement:
+		return "LetStatement", "", []field{{"Name", n.Name}, {"Value", n.Value}}
+	case *ast.ReturnStatement:
+		return "ReturnStatement", "", []field{{"ReturnValue", n.ReturnValue}}
+	case *ast.ExpressionStatement:
+		return "ExpressionStatement", "", []field{{"Expression", n.Expression}}
+	case *ast.ImportStatement:
+		return "ImportStatement", strconv.Quote(n.Path), []field{{"Alias", n.Alias}}
+	case *ast.Identifier:
+		return "Identifier", n.Value, nil
+	case *ast.IntegerLiteral:
+		return "IntegerLiteral", strconv.FormatInt(n.Value, 10), nil
+	case *ast.PrefixExpression:
+		return "PrefixExpression", n.Operator, []field{{"Right", n.Right}}
+	case *ast.InfixExpression:
+		return "InfixExpression", n.Operator, []field{{"Left", n.Left}, {"Right", n.Right}}
+	case *ast.MemberExpression:
+		return "MemberExpression", "", []field{{"Object", n.Object}, {"Member", n.Member}}
+	}
+	return fmt.Sprintf("%T", node), "", nil
+}
+
+// label 種類、値、位置を空白区切りで並べる
+func label(node ast.Node) string {
+	kind, value, _ := describe(node)
+	if value == "" {
+		return kind + " " + node.Pos().String()
+	}
+	return kind + " " + value + " " + node.Pos().String()
+}
+
+// Tree 1行に1ノード、子は2文字下げて書く
+//
+//	Program 1:1
+//	  Statements[0]: LetStatement 1:1
+//	    Name: Identifier x 1:5
+func Tree(w io.Writer, node ast.Node) error {
+	var b strings.Builder
+	tree(&b, "", node, 0)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func tree(b *strings.Builder, name string, node ast.Node, depth int) {
+	b.WriteString(strings.Repeat("  ", depth))
+	if name != "" {
+		b.WriteString(name + ": ")
+	}
+	if ast.IsNil(node) {
+		b.WriteString("<nil>\n")
+		return
+	}
+	b.WriteString(label(node) + "\n")
+
+	_, _, fields := describe(node)
+	for _, f := range fields {
+		tree(b, f.name, f.node, depth+1)
+	}
+}
+
+// Sexpr (種類 位置 値 子...)の形で書く。nilのノードはnil
+func Sexpr(w io.Writer, node ast.Node) error {
+	var b strings.Builder
+	sexpr(&b, node)
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func sexpr(b *strings.Builder, node ast.Node) {
+	if ast.IsNil(node) {
+		b.WriteString("nil")
+		return
+	}
+	kind, value, fields := describe(node)
+	b.WriteString("(" + kind + " " + node.Pos().String())
+	if value != "" {
+		b.WriteString(" " + value)
+	}
+	for _, f := range fields {
+		b.WriteString(" ")
+		sexpr(b, f.node)
+	}
+	b.WriteString(")")
+}
+
+// Dot GraphvizのDOTで書く。辺のラベルはフィールド名
+func Dot(w io.Writer, node ast.Node) error {
+	d := &dot{}
+	d.b.WriteString("digraph AST {\n")
+	d.b.WriteString("  node [shape=box, fontname=\"monospace\"];\n")
+	d.node(node)
+	d.b.WriteString("}\n")
+	_, err := io.WriteString(w, d.b.String())
+	return err
+}
+
+type dot struct {
+	b     strings.Builder
+	count int
+}
+
+// node ノードを書いてそのIDを返す
+func (d *dot) node(node ast.Node) string {
+	id := fmt.Sprintf("n%d", d.count)
+	d.count++
+
+	if ast.IsNil(node) {
+		fmt.Fprintf(&d.b, "  %s [label=\"nil\", shape=plaintext];\n", id)
+		return id
+	}
+
+	kind, value, fields := describe(node)
+	text := kind + "\n" + node.Pos().String()
+	if value != "" {
+		text = kind + "\n" + value + "\n" + node.Pos().String()
+	}
+	fmt.Fprintf(&d.b, "  %s [label=%s];\n", id, strconv.Quote(text))
+
+	for _, f := range fields {
+		child := d.node(f.node)
+		fmt.Fprintf(&d.b, "  %s -> %s [label=%s];\n", id, child, strconv.Quote(f.name))
+	}
+	return id
+}
@@ -0,0 +1,79 @@
+package dump
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/parser"
+)
+
+const input = `import "lib/m.mk" as m;
+let x = -1 + m.y * 2;`
+
+func TestTree(t *testing.T) {
+	expected := `Program 1:1
+  Statements[0]: ImportStatement "lib/m.mk" 1:1
+    Alias: Identifier m 1:22
+  Statements[1]: LetStatement 2:1
+    Name: Identifier x 2:5
+    Value: InfixExpression + 2:9
+      Left: PrefixExpression - 2:9
+        Right: IntegerLiteral 1 2:10
+      Right: InfixExpression * 2:14
+        Left: MemberExpression 2:14
+          Object: Identifier m 2:14
+          Member: Identifier y 2:16
+        Right: IntegerLiteral 2 2:20
+`
+	testFormat(t, "tree", input, expected)
+}
+
+func TestSexpr(t *testing.T) {
+	expected := `(Program 1:1 (ImportStatement 1:1 "lib/m.mk" (Identifier 1:22 m)) ` +
+		`(LetStatement 2:1 (Identifier 2:5 x) (InfixExpression 2:9 + (PrefixExpression 2:9 - (IntegerLiteral 2:10 1)) ` +
+		`(InfixExpression 2:14 * (MemberExpression 2:14 (Identifier 2:14 m) (Identifier 2:16 y)) (IntegerLiteral 2:20 2)))))
+`
+	testFormat(t, "sexpr", input, expected)
+}
+
+func TestDot(t *testing.T) {
+	expected := `digraph AST {
+  node [shape=box, fontname="monospace"];
+  n0 [label="Program\n1:1"];
+  n1 [label="LetStatement\n1:1"];
+  n2 [label="Identifier\nx\n1:5"];
+  n1 -> n2 [label="Name"];
+  n3 [label="nil", shape=plaintext];
+  n1 -> n3 [label="Value"];
+  n0 -> n1 [label="Statements[0]"];
+  n4 [label="ExpressionStatement\n1:11"];
+  n5 [label="IntegerLiteral\n1\n1:11"];
+  n4 -> n5 [label="Expression"];
+  n0 -> n4 [label="Statements[1]"];
+}
+`
+	testFormat(t, "dot", "let x = ; 1", expected)
+}
+
+func TestTreeNilStatement(t *testing.T) {
+	expected := `Program 1:5
+  Statements[0]: <nil>
+  Statements[1]: ExpressionStatement 1:5
+    Expression: IntegerLiteral 5 1:5
+`
+	testFormat(t, "tree", "let 5", expected)
+}
+
+func testFormat(t *testing.T, format string, src string, expected string) {
+	t.Helper()
+
+	program := parser.NewParser(lexer.NewLexer(src)).ParseProgram()
+	var out bytes.Buffer
+	if err := Formats[format](&out, program); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != expected {
+		t.Errorf("%s wrong.\ngot=\n%s\nwant=\n%s", format, out.String(), expected)
+	}
+}
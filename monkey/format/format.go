@@ -0,0 +1,285 @@
+package format
+
+// ASTからソースコードを組み立て直すプリティプリンタ。
+// * 文は1行に1つ、末尾には必ず「;」を付ける
+// * 演算子の前後には空白を1つ入れる
+// * 括弧は優先順位を変える場合にだけ付ける
+// * 元のソースにあった空行は1行にまとめて残す
+// * Sourceはコメントも残す。文の途中のコメントは直前のトークンの後ろに置く
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+	"github.com/tMinamiii/various-parser/monkey/parser"
+)
+
+// Source srcを構文解析して整形する。構文エラーがある場合は整形しない。
+// ASTにはコメントが残らないので、ソースから拾ったコメントを直前のトークンの後ろに戻す。
+func Source(src string) (string, error) {
+	p := parser.NewParser(lexer.NewLexer(src))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		return "", errors.New(strings.Join(errs, "\n"))
+	}
+	return render(program, attach(program, lexer.Tokenize(src))), nil
+}
+
+func Program(program *ast.Program) string {
+	return render(program, nil)
+}
+
+// comment 元のソースにあったコメント
+type comment struct {
+	lexer.Token
+	ownLine bool // 直前のトークンとは別の行にある
+}
+
+// placement どの文のどこにコメントを置くか。添字はprogram.Statements
+type placement struct {
+	leading  map[int][]comment // 文の前の行
+	inside   map[int][]comment // 文の途中。直前のトークンの後ろ
+	trailing map[int]comment   // 文の行末
+	footer   []comment         // 最後の文の後ろの行
+}
+
+// attach コメントを直前のトークンによって振り分ける。
+// 次のトークンが同じ文のものなら文の途中、そうでなければ直前のトークンと同じ行なら行末、
+// 別の行なら次の文の前に置く。
+func attach(program *ast.Program, tokens []lexer.Token) *placement {
+	pl := &placement{leading: map[int][]comment{}, inside: map[int][]comment{}, trailing: map[int]comment{}}
+	stmts := program.Statements
+
+	// stmt offsetを含む文の添字。最初の文より前なら-1
+	stmt := func(offset int) int {
+		i := 0
+		for i < len(stmts) && stmts[i].Pos().Offset <= offset {
+			i++
+		}
+		return i - 1
+	}
+
+	var prev *lexer.Token
+	for i := range tokens {
+		tok := tokens[i]
+		switch tok.Kind {
+		case lexer.KindWhitespace:
+			continue
+		case lexer.KindComment:
+		default:
+			if tok.Type != mtoken.EOF {
+				prev = &tokens[i]
+			}
+			continue
+		}
+
+		c := comment{Token: tok, ownLine: prev == nil || prev.Pos.Line != tok.Pos.Line}
+		owner := stmt(tok.Start)
+		next := nextCode(tokens[i+1:])
+		switch {
+		case owner >= 0 && next != nil && stmt(next.Start) == owner:
+			pl.inside[owner] = append(pl.inside[owner], c)
+		case owner >= 0 && !c.ownLine:
+			pl.trailing[owner] = c
+		case owner+1 < len(stmts):
+			pl.leading[owner+1] = append(pl.leading[owner+1], c)
+		default:
+			pl.footer = append(pl.footer, c)
+		}
+	}
+	return pl
+}
+
+// nextCode 空白とコメントを除いた最初のトークン
+func nextCode(tokens []lexer.Token) *lexer.Token {
+	for i, tok := range tokens {
+		if tok.Kind != lexer.KindWhitespace && tok.Kind != lexer.KindComment && tok.Type != mtoken.EOF {
+			return &tokens[i]
+		}
+	}
+	return nil
+}
+
+func render(program *ast.Program, pl *placement) string {
+	if pl == nil {
+		pl = &placement{}
+	}
+	var out bytes.Buffer
+
+	prevLine := 0
+	// line 元のソースで間に空行があった場合は1行だけ空ける
+	line := func(first, last int, text string) {
+		if prevLine > 0 && first > prevLine+1 {
+			out.WriteString("\n")
+		}
+		out.WriteString(text)
+		prevLine = last
+	}
+
+	for i, s := range program.Statements {
+		for _, c := range pl.leading[i] {
+			line(c.Pos.Line, c.Pos.Line, c.Text+"\n")
+		}
+
+		p := &printer{pending: pl.inside[i]}
+		p.statement(s)
+		last := lastLine(s)
+		if n := len(pl.inside[i]); n > 0 && pl.inside[i][n-1].Pos.Line > last {
+			last = pl.inside[i][n-1].Pos.Line
+		}
+		text := p.String()
+		if c, ok := pl.trailing[i]; ok {
+			text += " " + c.Text
+			last = c.Pos.Line
+		}
+		line(s.Pos().Line, last, text+"\n")
+	}
+
+	for _, c := range pl.footer {
+		line(c.Pos.Line, c.Pos.Line, c.Text+"\n")
+	}
+
+	return out.String()
+}
+
+func Statement(s ast.Statement) string {
+	p := &printer{}
+	p.statement(s)
+	return p.String()
+}
+
+func Expression(e ast.Expression) string {
+	p := &printer{}
+	p.expression(e, parser.LOWEST)
+	return p.String()
+}
+
+// printer 1つの文を組み立てる。
+// 文の途中にあったコメントは、元のソースでその後ろにあったトークンの前に書き出す。
+// コメントの後は改行し、続きの行はタブ1つで字下げする
+type printer struct {
+	bytes.Buffer
+	pending   []comment // まだ書いていない文の途中のコメント
+	space     bool      // 次のトークンの前に空白を入れる
+	lineStart bool      // コメントの後で改行したところ
+}
+
+// token posにあったトークンを書く。posより前のコメントを先に書き出す
+func (p *printer) token(pos mtoken.Position, text string) {
+	p.comments(pos.Offset)
+	p.word(text)
+}
+
+// word 元のソースでの位置を持たない記号を書く
+func (p *printer) word(text string) {
+	switch {
+	case p.lineStart:
+		p.WriteString("\t")
+	case p.space:
+		p.WriteString(" ")
+	}
+	p.WriteString(text)
+	p.space = false
+	p.lineStart = false
+}
+
+// comments offsetより前にあるコメントを書き出す
+func (p *printer) comments(offset int) {
+	for len(p.pending) > 0 && p.pending[0].Start < offset {
+		c := p.pending[0]
+		p.pending = p.pending[1:]
+		if c.ownLine {
+			if !p.lineStart {
+				p.WriteString("\n")
+			}
+			p.WriteString("\t" + c.Text + "\n")
+		} else {
+			p.WriteString(" " + c.Text + "\n")
+		}
+		p.lineStart = true
+	}
+}
+
+func (p *printer) statement(s ast.Statement) {
+	switch stmt := s.(type) {
+	case *ast.LetStatement:
+		p.token(stmt.Token.Pos, "let")
+		p.space = true
+		p.token(stmt.Name.Token.Pos, stmt.Name.Value)
+		p.space = true
+		p.word("=")
+		p.space = true
+		p.expression(stmt.Value, parser.LOWEST)
+	case *ast.ReturnStatement:
+		p.token(stmt.Token.Pos, "return")
+		if stmt.ReturnValue != nil {
+			p.space = true
+			p.expression(stmt.ReturnValue, parser.LOWEST)
+		}
+	case *ast.ImportStatement:
+		p.token(stmt.Token.Pos, "import")
+		p.space = true
+		p.word(`"` + stmt.Path + `"`)
+		p.space = true
+		p.word("as")
+		p.space = true
+		p.token(stmt.Alias.Token.Pos, stmt.Alias.Value)
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression, parser.LOWEST)
+	default:
+		p.word(strings.TrimSuffix(s.String(), ";"))
+	}
+	// 文の最後のトークンより後ろ、「;」の前にあったコメント
+	p.comments(math.MaxInt)
+	p.word(";")
+}
+
+// expression outerは外側の式の優先順位。それより弱く結合する式は括弧で囲む
+func (p *printer) expression(e ast.Expression, outer int) {
+	switch exp := e.(type) {
+	case *ast.Identifier:
+		p.token(exp.Token.Pos, exp.Value)
+	case *ast.IntegerLiteral:
+		p.token(exp.Token.Pos, exp.Token.Literal)
+	case *ast.PrefixExpression:
+		p.token(exp.Token.Pos, exp.Operator)
+		p.expression(exp.Right, parser.PREFIX)
+	case *ast.InfixExpression:
+		prec := parser.Precedence(exp.Token.Type)
+		if prec < outer {
+			p.word("(")
+		}
+		// 中置演算子は左結合なので、右側に同じ優先順位の式が来た場合は括弧が必要
+		p.expression(exp.Left, prec)
+		p.space = true
+		p.token(exp.Token.Pos, exp.Operator)
+		p.space = true
+		p.expression(exp.Right, prec+1)
+		if prec < outer {
+			p.word(")")
+		}
+	case *ast.MemberExpression:
+		p.expression(exp.Object, parser.MEMBER)
+		p.token(exp.Token.Pos, ".")
+		p.token(exp.Member.Token.Pos, exp.Member.Value)
+	default:
+		if e != nil {
+			p.word(e.String())
+		}
+	}
+}
+
+func lastLine(s ast.Statement) int {
+	line := 0
+	ast.Inspect(s, func(n ast.Node) bool {
+		if l := n.Pos().Line; l > line {
+			line = l
+		}
+		return true
+	})
+	return line
+}
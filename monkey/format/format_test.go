@@ -0,0 +1,137 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let  x=5", "let x = 5;\n"},
+		{"return a+b*c;", "return a + b * c;\n"},
+		{"return ;", "return;\n"},
+		{"a*b+c", "a * b + c;\n"},
+		{"-a - -b", "-a - -b;\n"},
+		{"a - b - c", "a - b - c;\n"},
+		{"!m.Ok == true_", "!m.Ok == true_;\n"},
+		{`import "lib/a.mk"  as  a;a.B`, "import \"lib/a.mk\" as a;\na.B;\n"},
+		{"let a = 1;\n\n\n\nlet b = a;\nb", "let a = 1;\n\nlet b = a;\nb;\n"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := Source(tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Source(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+
+		// 整形済みのソースをもう一度整形しても変わらない
+		again, err := Source(got)
+		if err != nil || again != got {
+			t.Errorf("Source(%q) is not idempotent. got=%q", got, again)
+		}
+	}
+
+	if _, err := Source("let = 1;"); err == nil {
+		t.Errorf("Source should fail on syntax errors")
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	input := `// header
+
+let a=1; // one
+// about b
+let b=a
+
+
+// trailing`
+	expected := `// header
+
+let a = 1; // one
+// about b
+let b = a;
+
+// trailing
+`
+	got, err := Source(input)
+	if err != nil {
+		t.Fatalf("Source returned error: %v", err)
+	}
+	if got != expected {
+		t.Errorf("Source() = %q, want %q", got, expected)
+	}
+
+	if got, _ := Source("// only"); got != "// only\n" {
+		t.Errorf("Source() = %q, want %q", got, "// only\n")
+	}
+}
+
+// 構文解析器が作れない木でも、優先順位が変わる所には括弧を付ける
+func TestExpressionParentheses(t *testing.T) {
+	ident := func(name string) ast.Expression {
+		return &ast.Identifier{Token: mtoken.Token{Type: mtoken.IDENT, Literal: name}, Value: name}
+	}
+	infix := func(left ast.Expression, op mtoken.TokenType, right ast.Expression) ast.Expression {
+		return &ast.InfixExpression{
+			Token:    mtoken.Token{Type: op, Literal: string(op)},
+			Left:     left,
+			Operator: string(op),
+			Right:    right,
+		}
+	}
+
+	tests := []struct {
+		input    ast.Expression
+		expected string
+	}{
+		{infix(infix(ident("a"), mtoken.PLUS, ident("b")), mtoken.ASTERISK, ident("c")), "(a + b) * c"},
+		{infix(ident("a"), mtoken.MINUS, infix(ident("b"), mtoken.MINUS, ident("c"))), "a - (b - c)"},
+		{&ast.PrefixExpression{Token: mtoken.Token{Type: mtoken.MINUS, Literal: "-"}, Operator: "-", Right: infix(ident("a"), mtoken.PLUS, ident("b"))}, "-(a + b)"},
+	}
+
+	for _, tt := range tests {
+		if got := Expression(tt.input); got != tt.expected {
+			t.Errorf("Expression() = %q, want %q", got, tt.expected)
+		}
+	}
+}
+
+func TestSourceCommentsInsideStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// 行末のコメントは同じ行の最後の文に付く
+		{"let a = 1; let b = 2; // c", "let a = 1;\nlet b = 2; // c\n"},
+		// 式の途中のコメントは直前のトークンの後ろに残る
+		{"let a = 1 + // one\n2;\nlet b = a;", "let a = 1 + // one\n\t2;\nlet b = a;\n"},
+		{"let a = 1 +\n  // one\n  2 * // two\n  3;", "let a = 1 +\n\t// one\n\t2 * // two\n\t3;\n"},
+		{"return m // member\n.x;", "return m // member\n\t.x;\n"},
+		{"let x = 1 // before semicolon\n;", "let x = 1 // before semicolon\n\t;\n"},
+	}
+
+	for _, tt := range tests {
+		got, err := Source(tt.input)
+		if err != nil {
+			t.Errorf("Source(%q) returned error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("Source(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+		again, err := Source(got)
+		if err != nil || again != got {
+			t.Errorf("Source(%q) is not idempotent. got=%q", got, again)
+		}
+	}
+}
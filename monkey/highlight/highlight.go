@@ -0,0 +1,59 @@
+package highlight
+
+// lexer.Tokenizeの分類をもとにソースコードに色を付ける。
+// 空白とコメントも含めて全てのトークンを出力するので、色を取り除けば元のソースに戻る。
+import (
+	"bytes"
+	"html"
+
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+)
+
+// ANSIColors 分類ごとのSGRシーケンス。空白には色を付けない
+var ANSIColors = map[lexer.Kind]string{
+	lexer.KindComment:     "\x1b[90m", // 灰
+	lexer.KindKeyword:     "\x1b[35m", // マゼンタ
+	lexer.KindOperator:    "\x1b[36m", // シアン
+	lexer.KindLiteral:     "\x1b[33m", // 黄
+	lexer.KindIdentifier:  "",
+	lexer.KindPunctuation: "",
+	lexer.KindIllegal:     "\x1b[4;31m", // 赤の下線
+}
+
+const ansiReset = "\x1b[0m"
+
+// ANSI 端末向けにエスケープシーケンスで色を付ける
+func ANSI(src string) string {
+	var out bytes.Buffer
+
+	for _, tok := range lexer.Tokenize(src) {
+		color := ANSIColors[tok.Kind]
+		if color == "" {
+			out.WriteString(tok.Text)
+			continue
+		}
+		out.WriteString(color)
+		out.WriteString(tok.Text)
+		out.WriteString(ansiReset)
+	}
+
+	return out.String()
+}
+
+// HTML 空白以外のトークンを<span class="mk-分類">で囲む。見た目はCSSで決める
+func HTML(src string) string {
+	var out bytes.Buffer
+
+	for _, tok := range lexer.Tokenize(src) {
+		text := html.EscapeString(tok.Text)
+		if tok.Kind == lexer.KindWhitespace {
+			out.WriteString(text)
+			continue
+		}
+		out.WriteString(`<span class="mk-` + tok.Kind.String() + `">`)
+		out.WriteString(text)
+		out.WriteString("</span>")
+	}
+
+	return out.String()
+}
@@ -0,0 +1,29 @@
+package highlight
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestANSI(t *testing.T) {
+	got := ANSI("let x = 5; // x\n@")
+	want := "\x1b[35mlet\x1b[0m x \x1b[36m=\x1b[0m \x1b[33m5\x1b[0m; \x1b[90m// x\x1b[0m\n\x1b[4;31m@\x1b[0m"
+	if got != want {
+		t.Errorf("ANSI() = %q, want %q", got, want)
+	}
+
+	// エスケープシーケンスを取り除けば元に戻る
+	sgr := regexp.MustCompile("\x1b\\[[0-9;]*m")
+	if plain := sgr.ReplaceAllString(got, ""); plain != "let x = 5; // x\n@" {
+		t.Errorf("ANSI() without colors = %q", plain)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got := HTML(`a < "<b>" // & c`)
+	want := `<span class="mk-identifier">a</span> <span class="mk-operator">&lt;</span> ` +
+		`<span class="mk-literal">&#34;&lt;b&gt;&#34;</span> <span class="mk-comment">// &amp; c</span>`
+	if got != want {
+		t.Errorf("HTML() = %q, want %q", got, want)
+	}
+}
@@ -1,47 +1,95 @@
 package lexer
 
-import "github.com/tMinamiii/various-parser/monkey/mtoken"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
 
 type Lexer struct {
 	input        string
 	position     int  // 入力における現在の位置
 	readPosition int  // 現在の文字の次
 	ch           byte // 現在操作中の文字
+	line         int  // 現在の文字の行番号
+	lineStart    int  // 現在の行の先頭の位置
 }
 
 func NewLexer(input string) *Lexer {
+	l := &Lexer{input: input, line: 1}
+	// 空文字列でもpanicしないようにreadCharで1文字目を読み込む
+	l.readChar()
+	return l
+}
+
+// NewLexerAt inputのpos.Offsetから字句解析を始める。posはその位置の行と列
+func NewLexerAt(input string, pos mtoken.Position) *Lexer {
 	l := &Lexer{
 		input:        input,
-		position:     0,
-		readPosition: 1,
-		ch:           input[0],
+		readPosition: pos.Offset,
+		line:         pos.Line,
+		lineStart:    pos.Offset - pos.Column + 1,
 	}
+	l.readChar()
 	return l
 }
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line += 1
+		l.lineStart = l.readPosition
+	}
 	if l.readPosition >= len(l.input) {
-		l.ch = 0 // 終端
-	} else {
-		l.ch = l.input[l.readPosition]
+		// 終端。何度呼ばれても位置は入力の末尾に留める
+		l.ch = 0
+		l.position = len(l.input)
+		return
 	}
+	l.ch = l.input[l.readPosition]
 	l.position = l.readPosition
 	l.readPosition += 1
 }
 
+// pos 現在の文字の位置
+func (l *Lexer) pos() mtoken.Position {
+	return mtoken.Position{
+		Offset: l.position,
+		Line:   l.line,
+		Column: l.position - l.lineStart + 1,
+	}
+}
+
 func (l *Lexer) newToken(tokenType mtoken.TokenType, ch byte) mtoken.Token {
 	return mtoken.Token{Type: tokenType, Literal: string(ch)}
 }
 
+// skipWhitespace 空白と「//」から始まる行コメントを読み飛ばす
 func (l *Lexer) skipWhitespace() {
-	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+	for {
+		if isWhitespace(l.ch) {
+			l.readChar()
+		} else if l.ch == '/' && l.peekChar() == '/' {
+			l.readComment()
+		} else {
+			return
+		}
+	}
+}
+
+// readComment 行末まで読み進める。改行は含めない
+func (l *Lexer) readComment() string {
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
+	return l.input[position:l.position]
 }
 
 func (l *Lexer) NextToken() mtoken.Token {
 	var tok mtoken.Token
 	l.skipWhitespace()
+	pos := l.pos()
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
@@ -85,22 +133,35 @@ func (l *Lexer) NextToken() mtoken.Token {
 		tok = l.newToken(mtoken.R_BRACE, l.ch)
 	case ',':
 		tok = l.newToken(mtoken.COMMA, l.ch)
+	case '.':
+		tok = l.newToken(mtoken.DOT, l.ch)
+	case '"':
+		tok.Type = mtoken.STRING
+		tok.Literal = l.readString()
+		if l.ch == 0 {
+			// 閉じていない文字列は、残りを全て読んだ上で「"」からILLEGALにする
+			tok.Type = mtoken.ILLEGAL
+			tok.Literal = `"` + tok.Literal
+		}
 	case 0:
 		tok = mtoken.Token{Type: mtoken.EOF, Literal: ""}
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = mtoken.LookupIdent(tok.Literal)
+			tok.Pos = pos
 			return tok
 		} else if isDigit(l.ch) {
 			tok.Literal = l.readNumber()
 			tok.Type = mtoken.INT
+			tok.Pos = pos
 			return tok
 		} else {
 			tok = l.newToken(mtoken.ILLEGAL, l.ch)
 		}
 	}
 	l.readChar()
+	tok.Pos = pos
 	return tok
 }
 
@@ -121,6 +182,26 @@ func (l *Lexer) readNumber() string {
 	return string(number)
 }
 
+// readString 閉じる「"」か終端まで読み進める。エスケープはサポートしない
+func (l *Lexer) readString() string {
+	position := l.position + 1
+	for {
+		l.readChar()
+		if l.ch == '"' || l.ch == 0 {
+			break
+		}
+	}
+	return l.input[position:l.position]
+}
+
+// IllegalMessage ILLEGALのトークンのエラーメッセージ。閉じていない文字列と不正な文字を分ける
+func IllegalMessage(tok mtoken.Token) string {
+	if strings.HasPrefix(tok.Literal, `"`) {
+		return "unterminated string"
+	}
+	return fmt.Sprintf("illegal character %q", tok.Literal)
+}
+
 func (l *Lexer) readIdentifier() string {
 	var ident []rune
 	// 文字(a-z, A-Z, _)が続く限り読み進める
@@ -131,6 +212,10 @@ func (l *Lexer) readIdentifier() string {
 	return string(ident)
 }
 
+func isWhitespace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
 func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' ||
 		'A' <= ch && ch <= 'Z' ||
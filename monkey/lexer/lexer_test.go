@@ -132,15 +132,83 @@ if (5 < 10) {
 				{Type: mtoken.EOF, Literal: ""},
 			},
 		},
+		{
+			name: "import",
+			fields: fields{
+				input: `import "lib/math.mk" as m;
+m.Add;
+"unterminated`,
+			},
+			want: []mtoken.Token{
+				{Type: mtoken.IMPORT, Literal: "import"},
+				{Type: mtoken.STRING, Literal: "lib/math.mk"},
+				{Type: mtoken.AS, Literal: "as"},
+				{Type: mtoken.IDENT, Literal: "m"},
+				{Type: mtoken.SEMICOLON, Literal: ";"},
+				{Type: mtoken.IDENT, Literal: "m"},
+				{Type: mtoken.DOT, Literal: "."},
+				{Type: mtoken.IDENT, Literal: "Add"},
+				{Type: mtoken.SEMICOLON, Literal: ";"},
+				{Type: mtoken.ILLEGAL, Literal: `"unterminated`},
+				{Type: mtoken.EOF, Literal: ""},
+			},
+		},
+		{
+			name: "unterminated string",
+			fields: fields{
+				input: `import "a.mk as m;
+x;`,
+			},
+			want: []mtoken.Token{
+				// 閉じていない文字列は残りを全て含むILLEGALになる
+				{Type: mtoken.IMPORT, Literal: "import"},
+				{Type: mtoken.ILLEGAL, Literal: "\"a.mk as m;\nx;"},
+				{Type: mtoken.EOF, Literal: ""},
+			},
+		},
+		{
+			name: "empty",
+			fields: fields{
+				input: ``,
+			},
+			want: []mtoken.Token{
+				{Type: mtoken.EOF, Literal: ""},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			l := NewLexer(tt.fields.input)
 			for i, w := range tt.want {
-				if got := l.NextToken(); !reflect.DeepEqual(got, w) {
+				// 位置情報はTestLexer_NextTokenPositionで確認する
+				if got := l.NextToken(); got.Type != w.Type || got.Literal != w.Literal {
 					t.Errorf("tests[%d] - Lexer.NextToken() = %v, want %v", i, got, w)
 				}
 			}
 		})
 	}
 }
+
+func TestLexer_NextTokenPosition(t *testing.T) {
+	input := "let x = 10;\n\tx == \"ab\";\n"
+	want := []mtoken.Position{
+		{Offset: 0, Line: 1, Column: 1},   // let
+		{Offset: 4, Line: 1, Column: 5},   // x
+		{Offset: 6, Line: 1, Column: 7},   // =
+		{Offset: 8, Line: 1, Column: 9},   // 10
+		{Offset: 10, Line: 1, Column: 11}, // ;
+		{Offset: 13, Line: 2, Column: 2},  // x
+		{Offset: 15, Line: 2, Column: 4},  // ==
+		{Offset: 18, Line: 2, Column: 7},  // "ab"
+		{Offset: 22, Line: 2, Column: 11}, // ;
+		{Offset: 24, Line: 3, Column: 1},  // EOF
+		{Offset: 24, Line: 3, Column: 1},  // EOF
+	}
+
+	l := NewLexer(input)
+	for i, w := range want {
+		if got := l.NextToken(); !reflect.DeepEqual(got.Pos, w) {
+			t.Errorf("tests[%d] - Lexer.NextToken().Pos = %v, want %v", i, got.Pos, w)
+		}
+	}
+}
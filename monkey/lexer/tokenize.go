@@ -0,0 +1,116 @@
+package lexer
+
+import (
+	"unicode/utf8"
+
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+// Kind シンタックスハイライト用のトークンの分類
+type Kind int
+
+const (
+	KindWhitespace Kind = iota
+	KindComment
+	KindKeyword
+	KindOperator
+	KindLiteral
+	KindIdentifier
+	KindPunctuation
+	KindIllegal
+)
+
+var kindNames = map[Kind]string{
+	KindWhitespace:  "whitespace",
+	KindComment:     "comment",
+	KindKeyword:     "keyword",
+	KindOperator:    "operator",
+	KindLiteral:     "literal",
+	KindIdentifier:  "identifier",
+	KindPunctuation: "punctuation",
+	KindIllegal:     "illegal",
+}
+
+func (k Kind) String() string {
+	return kindNames[k]
+}
+
+// Classify トークンタイプを分類する。true/falseはキーワードではなくリテラルとして扱う
+func Classify(t mtoken.TokenType) Kind {
+	switch t {
+	case mtoken.WHITESPACE:
+		return KindWhitespace
+	case mtoken.COMMENT:
+		return KindComment
+	case mtoken.INT, mtoken.STRING, mtoken.TRUE, mtoken.FALSE:
+		return KindLiteral
+	case mtoken.IDENT:
+		return KindIdentifier
+	case mtoken.ASSIGN, mtoken.PLUS, mtoken.MINUS, mtoken.BANG, mtoken.ASTERISK, mtoken.SLASH,
+		mtoken.LT, mtoken.GT, mtoken.EQ, mtoken.NOT_EQ, mtoken.DOT:
+		return KindOperator
+	case mtoken.COMMA, mtoken.SEMICOLON, mtoken.L_PAREN, mtoken.R_PAREN, mtoken.L_BRACE, mtoken.R_BRACE:
+		return KindPunctuation
+	}
+	if mtoken.IsKeyword(t) {
+		return KindKeyword
+	}
+	return KindIllegal
+}
+
+// Token Tokenizeが返すトークン。Textはソースの[Start, End)をそのまま切り出したもの
+type Token struct {
+	mtoken.Token
+	Kind  Kind
+	Start int
+	End   int
+	Text  string
+}
+
+// Tokenize 空白やコメントも含めてsrcを全てトークンに分ける。
+// 全てのトークンのTextを連結するとsrcと完全に一致する。EOFトークンは含めない。
+func Tokenize(src string) []Token {
+	l := NewLexer(src)
+	var tokens []Token
+
+	for l.position < len(src) {
+		start := l.position
+		pos := l.pos()
+
+		var tok mtoken.Token
+		switch {
+		case isWhitespace(l.ch):
+			for isWhitespace(l.ch) {
+				l.readChar()
+			}
+			tok = mtoken.Token{Type: mtoken.WHITESPACE, Literal: src[start:l.position]}
+		case l.ch == '/' && l.peekChar() == '/':
+			tok = mtoken.Token{Type: mtoken.COMMENT, Literal: l.readComment()}
+		case l.ch == 0:
+			// NextTokenは途中のNUL文字を終端とみなすので、ここで1バイトだけ進める
+			l.readChar()
+			tok = mtoken.Token{Type: mtoken.ILLEGAL, Literal: src[start:l.position]}
+		default:
+			tok = l.NextToken()
+			if tok.Type == mtoken.ILLEGAL && src[start] >= utf8.RuneSelf {
+				// 字句解析器はバイト単位なので、マルチバイト文字は1文字分まとめる
+				_, size := utf8.DecodeRuneInString(src[start:])
+				for l.position < start+size {
+					l.readChar()
+				}
+				tok.Literal = src[start:l.position]
+			}
+		}
+
+		tok.Pos = pos
+		tokens = append(tokens, Token{
+			Token: tok,
+			Kind:  Classify(tok.Type),
+			Start: start,
+			End:   l.position,
+			Text:  src[start:l.position],
+		})
+	}
+
+	return tokens
+}
@@ -0,0 +1,90 @@
+package lexer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+func TestTokenize(t *testing.T) {
+	input := "let x = 5; // five\n\tx == \"é\""
+
+	want := []struct {
+		typ   mtoken.TokenType
+		kind  Kind
+		text  string
+		start int
+	}{
+		{mtoken.LET, KindKeyword, "let", 0},
+		{mtoken.WHITESPACE, KindWhitespace, " ", 3},
+		{mtoken.IDENT, KindIdentifier, "x", 4},
+		{mtoken.WHITESPACE, KindWhitespace, " ", 5},
+		{mtoken.ASSIGN, KindOperator, "=", 6},
+		{mtoken.WHITESPACE, KindWhitespace, " ", 7},
+		{mtoken.INT, KindLiteral, "5", 8},
+		{mtoken.SEMICOLON, KindPunctuation, ";", 9},
+		{mtoken.WHITESPACE, KindWhitespace, " ", 10},
+		{mtoken.COMMENT, KindComment, "// five", 11},
+		{mtoken.WHITESPACE, KindWhitespace, "\n\t", 18},
+		{mtoken.IDENT, KindIdentifier, "x", 20},
+		{mtoken.WHITESPACE, KindWhitespace, " ", 21},
+		{mtoken.EQ, KindOperator, "==", 22},
+		{mtoken.WHITESPACE, KindWhitespace, " ", 24},
+		{mtoken.STRING, KindLiteral, "\"é\"", 25},
+	}
+
+	got := Tokenize(input)
+	if len(got) != len(want) {
+		t.Fatalf("Tokenize returned %d tokens, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Type != w.typ || g.Kind != w.kind || g.Text != w.text || g.Start != w.start || g.End != w.start+len(w.text) {
+			t.Errorf("tokens[%d] = %+v, want %+v", i, g, w)
+		}
+	}
+	if got[11].Pos.Line != 2 || got[11].Pos.Column != 2 {
+		t.Errorf("tokens[11].Pos = %v, want 2:2", got[11].Pos)
+	}
+}
+
+// 連結すれば必ず元の入力に戻る
+func TestTokenizeRoundTrip(t *testing.T) {
+	inputs := []string{
+		"",
+		"   ",
+		"// only a comment",
+		"let a = 1;\r\n// c\r\nreturn a;",
+		"@#$ 日本語 ~",
+		"\"unterminated string",
+		"a\x00b",
+		"x / / y //",
+		"\xff\xfe",
+	}
+
+	for _, input := range inputs {
+		var b strings.Builder
+		end := 0
+		for _, tok := range Tokenize(input) {
+			if tok.Start != end {
+				t.Errorf("%q: token %+v does not start where the previous one ended (%d)", input, tok, end)
+			}
+			end = tok.End
+			b.WriteString(tok.Text)
+		}
+		if b.String() != input {
+			t.Errorf("Tokenize(%q) joined = %q", input, b.String())
+		}
+	}
+}
+
+func TestNextTokenSkipsComments(t *testing.T) {
+	l := NewLexer("// header\na // trailing\n/ b //")
+	want := []mtoken.TokenType{mtoken.IDENT, mtoken.SLASH, mtoken.IDENT, mtoken.EOF}
+	for i, w := range want {
+		if got := l.NextToken(); got.Type != w {
+			t.Errorf("tests[%d] - NextToken().Type = %q, want %q", i, got.Type, w)
+		}
+	}
+}
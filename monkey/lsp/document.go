@@ -0,0 +1,212 @@
+package lsp
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/checker"
+	"github.com/tMinamiii/various-parser/monkey/format"
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/parser"
+)
+
+type document struct {
+	uri        string
+	version    int
+	text       string
+	lineStarts []int // 各行の先頭のバイトオフセット
+
+	program     *ast.Program
+	parseErrors bool
+	diagnostics []Diagnostic
+	bindings    []*checker.Binding
+	references  []*checker.Reference // オフセット順
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{uri: uri, version: version, text: text}
+
+	d.lineStarts = []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+
+	d.analyze()
+	return d
+}
+
+func (d *document) analyze() {
+	p := parser.NewParser(lexer.NewLexer(d.text))
+	d.program = p.ParseProgram()
+	d.diagnostics = []Diagnostic{}
+
+	for _, e := range p.ParseErrors() {
+		d.diagnostics = append(d.diagnostics, d.diagnostic(e.Pos.Offset, e.Msg))
+	}
+	d.parseErrors = len(p.ParseErrors()) > 0
+
+	// 構文エラーがあると型付きnilの文が混ざるので意味解析はしない
+	if !d.parseErrors {
+		for _, e := range checker.Check(d.program) {
+			d.diagnostics = append(d.diagnostics, d.diagnostic(e.Pos.Offset, e.Msg))
+		}
+	}
+
+	d.bindings, d.references = checker.Resolve(d.program)
+}
+
+func (d *document) diagnostic(offset int, msg string) Diagnostic {
+	return Diagnostic{
+		Range:    d.rangeOf(offset, d.wordEnd(offset)),
+		Severity: severityError,
+		Source:   "monkey",
+		Message:  msg,
+	}
+}
+
+// referenceAt カーソルが識別子の上か直後にあればそれを返す
+func (d *document) referenceAt(pos Position) *checker.Reference {
+	offset := d.offsetOf(pos)
+	for _, r := range d.references {
+		start := r.Ident.Token.Pos.Offset
+		if start <= offset && offset <= start+len(r.Ident.Value) {
+			return r
+		}
+	}
+	return nil
+}
+
+func (d *document) identRange(ident *ast.Identifier) Range {
+	start := ident.Token.Pos.Offset
+	return d.rangeOf(start, start+len(ident.Value))
+}
+
+// stmtRange 文の先頭から最後のトークン(「;」があればそれも含む)まで
+func (d *document) stmtRange(s ast.Statement) Range {
+	start := s.Pos().Offset
+	end := start + len(s.TokenLiteral())
+	ast.Inspect(s, func(n ast.Node) bool {
+		if e := n.Pos().Offset + len(n.TokenLiteral()); e > end {
+			end = e
+		}
+		return true
+	})
+	if end < len(d.text) && d.text[end] == ';' {
+		end++
+	}
+	return d.rangeOf(start, end)
+}
+
+func (d *document) hover(pos Position) *Hover {
+	r := d.referenceAt(pos)
+	if r == nil || r.Binding == nil {
+		return nil
+	}
+
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: "```monkey\n" + format.Statement(r.Binding.Stmt) + "\n```",
+		},
+		Range: d.identRange(r.Ident),
+	}
+}
+
+func (d *document) definition(pos Position) *Location {
+	r := d.referenceAt(pos)
+	if r == nil || r.Binding == nil {
+		return nil
+	}
+	return &Location{URI: d.uri, Range: d.identRange(r.Binding.Name)}
+}
+
+func (d *document) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, b := range d.bindings {
+		kind := symbolKindVariable
+		if _, ok := b.Stmt.(*ast.ImportStatement); ok {
+			kind = symbolKindModule
+		}
+		symbols = append(symbols, DocumentSymbol{
+			Name:           b.Name.Value,
+			Detail:         format.Statement(b.Stmt),
+			Kind:           kind,
+			Range:          d.stmtRange(b.Stmt),
+			SelectionRange: d.identRange(b.Name),
+		})
+	}
+	return symbols
+}
+
+// formatting 構文エラーがある場合は整形しない。コメントは直前のトークンの後ろに残す
+func (d *document) formatting() []TextEdit {
+	formatted, err := format.Source(d.text)
+	if err != nil || formatted == d.text {
+		return []TextEdit{}
+	}
+	return []TextEdit{{Range: d.rangeOf(0, len(d.text)), NewText: formatted}}
+}
+
+// wordEnd offsetから始まる識別子や数値の終わり。記号なら1文字分
+func (d *document) wordEnd(offset int) int {
+	end := offset
+	for end < len(d.text) && isWordChar(d.text[end]) {
+		end++
+	}
+	if end == offset && end < len(d.text) {
+		_, size := utf8.DecodeRuneInString(d.text[end:])
+		end += size
+	}
+	return end
+}
+
+func isWordChar(ch byte) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || '0' <= ch && ch <= '9' || ch == '_'
+}
+
+func (d *document) rangeOf(start, end int) Range {
+	return Range{Start: d.positionOf(start), End: d.positionOf(end)}
+}
+
+// positionOf バイトオフセットをLSPの位置に変換する
+func (d *document) positionOf(offset int) Position {
+	if offset > len(d.text) {
+		offset = len(d.text)
+	}
+	line := sort.Search(len(d.lineStarts), func(i int) bool { return d.lineStarts[i] > offset }) - 1
+
+	character := 0
+	for _, r := range d.text[d.lineStarts[line]:offset] {
+		character += utf16Len(r)
+	}
+	return Position{Line: line, Character: character}
+}
+
+// offsetOf LSPの位置をバイトオフセットに変換する。行末を越える位置は行末に丸める
+func (d *document) offsetOf(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lineStarts) {
+		return len(d.text)
+	}
+
+	offset := d.lineStarts[pos.Line]
+	character := 0
+	for offset < len(d.text) && d.text[offset] != '\n' && character < pos.Character {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		character += utf16Len(r)
+		offset += size
+	}
+	return offset
+}
+
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
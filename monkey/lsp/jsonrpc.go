@@ -0,0 +1,112 @@
+package lsp
+
+// LSPのメッセージはHTTPに似たヘッダとJSON-RPCの本文からなる。
+//
+//	Content-Length: 52\r\n
+//	\r\n
+//	{"jsonrpc":"2.0","id":1,"method":"initialize",...}
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// request idがないものは通知
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+func (r *request) isNotification() bool {
+	return len(r.ID) == 0
+}
+
+// response 成功時はresultがnullでも必ず含める必要があるのでomitemptyを付けない
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Error   *responseError  `json:"error"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// maxContentLength 本文の上限。ヘッダ1つで巨大なメモリを確保させない
+const maxContentLength = 64 << 20
+
+// headerError Content-Lengthが不正。lengthが0以上なら、その分の本文を読み飛ばせば次のメッセージを読める
+type headerError struct {
+	msg    string
+	length int
+}
+
+func (e *headerError) Error() string {
+	return e.msg
+}
+
+// readMessage ヘッダを読み、Content-Lengthバイトの本文を返す
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	value := header.Get("Content-Length")
+	length, err := strconv.Atoi(value)
+	if err != nil || length < 0 {
+		return nil, &headerError{msg: fmt.Sprintf("invalid Content-Length: %q", value), length: -1}
+	}
+	if length > maxContentLength {
+		msg := fmt.Sprintf("Content-Length %d exceeds the limit of %d bytes", length, maxContentLength)
+		return nil, &headerError{msg: msg, length: length}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func writeMessage(w io.Writer, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
@@ -0,0 +1,246 @@
+package lsp
+
+// 標準入出力でJSON-RPCを話すMonkeyのLanguage Server。
+// ドキュメントは全文同期で受け取り、変更のたびに構文解析し直して診断を送る。
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrExitWithoutShutdown shutdownを受け取る前にexitが来た。仕様では終了コード1で終わる
+var ErrExitWithoutShutdown = errors.New("exit notification received before shutdown")
+
+type Server struct {
+	in   *bufio.Reader
+	out  io.Writer
+	docs map[string]*document
+
+	shutdown bool
+}
+
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve exit通知を受け取るか入力が終わるまでリクエストを処理する
+func (s *Server) Serve() error {
+	for {
+		body, err := readMessage(s.in)
+		if err == io.EOF {
+			if s.shutdown {
+				return nil
+			}
+			return ErrExitWithoutShutdown
+		}
+		var herr *headerError
+		if errors.As(err, &herr) {
+			if err := s.replyError(nil, codeInvalidRequest, herr.msg); err != nil {
+				return err
+			}
+			// 長さが分からなければ次のメッセージの区切りも分からないので終了する
+			if herr.length < 0 {
+				return herr
+			}
+			// 上限を超えた本文はメモリに載せずに読み飛ばす
+			if _, err := io.CopyN(io.Discard, s.in, int64(herr.length)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.replyError(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if req.Method == "exit" {
+			if s.shutdown {
+				return nil
+			}
+			return ErrExitWithoutShutdown
+		}
+
+		if err := s.handle(&req); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req *request) error {
+	if req.isNotification() {
+		return s.handleNotification(req)
+	}
+
+	var result interface{}
+	var err error
+	switch req.Method {
+	case "initialize":
+		result = s.initialize()
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/hover":
+		result, err = s.hover(req.Params)
+	case "textDocument/definition":
+		result, err = s.definition(req.Params)
+	case "textDocument/documentSymbol":
+		result, err = s.documentSymbol(req.Params)
+	case "textDocument/formatting":
+		result, err = s.formatting(req.Params)
+	default:
+		return s.replyError(req.ID, codeMethodNotFound, "method not found: "+req.Method)
+	}
+
+	if err != nil {
+		rerr, ok := err.(*responseError)
+		if !ok {
+			rerr = &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		return s.replyError(req.ID, rerr.Code, rerr.Message)
+	}
+	return writeMessage(s.out, &response{JSONRPC: "2.0", ID: req.ID, Result: result})
+}
+
+// handleNotification 知らない通知は仕様どおり無視する
+func (s *Server) handleNotification(req *request) error {
+	switch req.Method {
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		item := params.TextDocument
+		return s.update(newDocument(item.URI, item.Version, item.Text))
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil || len(params.ContentChanges) == 0 {
+			return nil
+		}
+		// 全文同期なので最後の変更が最新の全文になる
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return s.update(newDocument(params.TextDocument.URI, params.TextDocument.Version, text))
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil
+		}
+		delete(s.docs, params.TextDocument.URI)
+		return s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+	}
+	return nil
+}
+
+func (s *Server) update(d *document) error {
+	s.docs[d.uri] = d
+	return s.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         d.uri,
+		Version:     d.version,
+		Diagnostics: d.diagnostics,
+	})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, &notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *Server) replyError(id json.RawMessage, code int, msg string) error {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return writeMessage(s.out, &errorResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &responseError{Code: code, Message: msg},
+	})
+}
+
+func (s *Server) initialize() *InitializeResult {
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync:           textDocumentSyncFull,
+			HoverProvider:              true,
+			DefinitionProvider:         true,
+			DocumentSymbolProvider:     true,
+			DocumentFormattingProvider: true,
+		},
+		ServerInfo: ServerInfo{Name: "monkey-lsp"},
+	}
+}
+
+func decodeParams(raw json.RawMessage, params interface{}) error {
+	if err := json.Unmarshal(raw, params); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	d, ok := s.docs[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "document not open: " + uri}
+	}
+	return d, nil
+}
+
+// hover 識別子が見つからなければnullを返す
+func (s *Server) hover(raw json.RawMessage) (interface{}, error) {
+	var params TextDocumentPositionParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return d.hover(params.Position), nil
+}
+
+func (s *Server) definition(raw json.RawMessage) (interface{}, error) {
+	var params TextDocumentPositionParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return d.definition(params.Position), nil
+}
+
+func (s *Server) documentSymbol(raw json.RawMessage) (interface{}, error) {
+	var params DocumentSymbolParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return d.symbols(), nil
+}
+
+func (s *Server) formatting(raw json.RawMessage) (interface{}, error) {
+	var params DocumentFormattingParams
+	if err := decodeParams(raw, &params); err != nil {
+		return nil, err
+	}
+	d, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return d.formatting(), nil
+}
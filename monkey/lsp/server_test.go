@@ -0,0 +1,439 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testClient エディタの代わりにServerとパイプでつながり、リクエストを送る
+type testClient struct {
+	t        *testing.T
+	w        io.WriteCloser
+	messages chan map[string]json.RawMessage
+	pending  []map[string]json.RawMessage // まだ読まれていない通知
+	done     chan error
+	nextID   int
+}
+
+func newTestClient(t *testing.T) *testClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &testClient{
+		t:        t,
+		w:        clientOut,
+		messages: make(chan map[string]json.RawMessage, 100),
+		done:     make(chan error, 1),
+	}
+
+	go func() {
+		c.done <- NewServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+	}()
+
+	// Serverは通知を書き込み終わるまで次のリクエストを読まないので、常に読み続けておく
+	go func() {
+		r := bufio.NewReader(clientIn)
+		for {
+			body, err := readMessage(r)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			var msg map[string]json.RawMessage
+			if err := json.Unmarshal(body, &msg); err != nil {
+				t.Errorf("server sent invalid JSON: %s", body)
+			}
+			c.messages <- msg
+		}
+	}()
+
+	return c
+}
+
+func (c *testClient) send(v interface{}) {
+	c.t.Helper()
+	if err := writeMessage(c.w, v); err != nil {
+		c.t.Fatalf("writeMessage: %v", err)
+	}
+}
+
+func (c *testClient) next() map[string]json.RawMessage {
+	c.t.Helper()
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatalf("server closed the connection")
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatalf("timed out waiting for the server")
+	}
+	return nil
+}
+
+// call リクエストを送り、レスポンスのresultをresultにデコードする
+func (c *testClient) call(method string, params, result interface{}) *responseError {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(fmt.Sprint(c.nextID))
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+
+	for {
+		msg := c.next()
+		if _, ok := msg["id"]; !ok {
+			c.pending = append(c.pending, msg)
+			continue
+		}
+		if string(msg["id"]) != string(id) {
+			c.t.Fatalf("response id = %s, want %s", msg["id"], id)
+		}
+		if raw, ok := msg["error"]; ok {
+			var rerr responseError
+			if err := json.Unmarshal(raw, &rerr); err != nil {
+				c.t.Fatalf("invalid error: %s", raw)
+			}
+			return &rerr
+		}
+		raw, ok := msg["result"]
+		if !ok {
+			c.t.Fatalf("response has neither result nor error")
+		}
+		if result != nil {
+			if err := json.Unmarshal(raw, result); err != nil {
+				c.t.Fatalf("invalid result %s: %v", raw, err)
+			}
+		}
+		return nil
+	}
+}
+
+func (c *testClient) notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// diagnostics 次のpublishDiagnostics通知を待つ
+func (c *testClient) diagnostics() PublishDiagnosticsParams {
+	c.t.Helper()
+	var msg map[string]json.RawMessage
+	if len(c.pending) > 0 {
+		msg, c.pending = c.pending[0], c.pending[1:]
+	} else {
+		msg = c.next()
+	}
+
+	var method string
+	json.Unmarshal(msg["method"], &method)
+	if method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("expected publishDiagnostics, got %q", method)
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msg["params"], &params); err != nil {
+		c.t.Fatalf("invalid publishDiagnostics: %v", err)
+	}
+	return params
+}
+
+func (c *testClient) open(uri, text string) PublishDiagnosticsParams {
+	c.t.Helper()
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "monkey", Version: 1, Text: text},
+	})
+	return c.diagnostics()
+}
+
+func (c *testClient) shutdown() {
+	c.t.Helper()
+	if err := c.call("shutdown", nil, nil); err != nil {
+		c.t.Fatalf("shutdown: %v", err)
+	}
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		c.t.Fatalf("Serve returned error: %v", err)
+	}
+}
+
+func pos(line, character int) Position {
+	return Position{Line: line, Character: character}
+}
+
+func rng(startLine, startChar, endLine, endChar int) Range {
+	return Range{Start: pos(startLine, startChar), End: pos(endLine, endChar)}
+}
+
+func TestInitialize(t *testing.T) {
+	c := newTestClient(t)
+
+	var result InitializeResult
+	if err := c.call("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, &result); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	c.notify("initialized", map[string]interface{}{})
+
+	caps := result.Capabilities
+	if caps.TextDocumentSync != textDocumentSyncFull || !caps.HoverProvider || !caps.DefinitionProvider ||
+		!caps.DocumentSymbolProvider || !caps.DocumentFormattingProvider {
+		t.Errorf("unexpected capabilities: %+v", caps)
+	}
+
+	if err := c.call("textDocument/unknown", nil, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("unknown method error = %v, want code %d", err, codeMethodNotFound)
+	}
+
+	c.shutdown()
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newTestClient(t)
+	c.notify("exit", nil)
+	if err := <-c.done; err != ErrExitWithoutShutdown {
+		t.Errorf("Serve returned %v, want %v", err, ErrExitWithoutShutdown)
+	}
+}
+
+func TestContentLength(t *testing.T) {
+	// 上限を超える本文は確保せずに読み飛ばし、次のメッセージから続ける
+	c := newTestClient(t)
+	go func() {
+		fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", maxContentLength+1)
+		c.w.Write(make([]byte, maxContentLength+1))
+	}()
+	expectHeaderError(t, c.next(), "Content-Length 67108865 exceeds the limit of 67108864 bytes")
+	c.shutdown()
+
+	// 負の長さでは続きを読めないので、エラーを返して終了する
+	c = newTestClient(t)
+	go fmt.Fprint(c.w, "Content-Length: -1\r\n\r\n")
+	expectHeaderError(t, c.next(), `invalid Content-Length: "-1"`)
+	if err := <-c.done; err == nil || err.Error() != `invalid Content-Length: "-1"` {
+		t.Errorf("Serve returned %v", err)
+	}
+}
+
+func expectHeaderError(t *testing.T, msg map[string]json.RawMessage, message string) {
+	t.Helper()
+	var rerr responseError
+	if err := json.Unmarshal(msg["error"], &rerr); err != nil {
+		t.Fatalf("expected an error response, got %v", msg)
+	}
+	if string(msg["id"]) != "null" || rerr.Code != codeInvalidRequest || rerr.Message != message {
+		t.Errorf("error = %s %+v, want code %d %q", msg["id"], rerr, codeInvalidRequest, message)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newTestClient(t)
+	uri := "file:///test.mk"
+
+	got := c.open(uri, "let x = 1;\nlet = 2;\n")
+	want := []Diagnostic{
+		{Range: rng(1, 4, 1, 5), Severity: severityError, Source: "monkey", Message: "expected next token to be IDENT, got = instead"},
+		{Range: rng(1, 4, 1, 5), Severity: severityError, Source: "monkey", Message: "no prefix parse function for = found"},
+	}
+	if got.URI != uri || !reflect.DeepEqual(got.Diagnostics, want) {
+		t.Errorf("diagnostics = %+v, want %+v", got.Diagnostics, want)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let x = 1;\nlet x = y + x;\n"}},
+	})
+	got = c.diagnostics()
+	want = []Diagnostic{
+		{Range: rng(1, 4, 1, 5), Severity: severityError, Source: "monkey", Message: "x redeclared"},
+		{Range: rng(1, 8, 1, 9), Severity: severityError, Source: "monkey", Message: "undefined: y"},
+	}
+	if got.Version != 2 || !reflect.DeepEqual(got.Diagnostics, want) {
+		t.Errorf("diagnostics = %+v, want %+v", got.Diagnostics, want)
+	}
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 3},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let x = 1;"}},
+	})
+	if got := c.diagnostics(); len(got.Diagnostics) != 0 {
+		t.Errorf("diagnostics = %+v, want none", got.Diagnostics)
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+	if got := c.diagnostics(); got.URI != uri || len(got.Diagnostics) != 0 {
+		t.Errorf("diagnostics after close = %+v, want none", got)
+	}
+	if err := c.call("textDocument/hover", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+	}, nil); err == nil {
+		t.Errorf("hover on a closed document should fail")
+	}
+
+	c.shutdown()
+}
+
+const navigationSource = `import "lib.mk" as lib;
+let answer = 42;
+let total = answer * lib.Scale;
+total;
+`
+
+func TestHover(t *testing.T) {
+	c := newTestClient(t)
+	uri := "file:///nav.mk"
+	c.open(uri, navigationSource)
+
+	tests := []struct {
+		pos   Position
+		value string
+		rng   Range
+	}{
+		{pos(2, 13), "```monkey\nlet answer = 42;\n```", rng(2, 12, 2, 18)},
+		{pos(2, 18), "```monkey\nlet answer = 42;\n```", rng(2, 12, 2, 18)},
+		{pos(2, 21), "```monkey\nimport \"lib.mk\" as lib;\n```", rng(2, 21, 2, 24)},
+		{pos(3, 0), "```monkey\nlet total = answer * lib.Scale;\n```", rng(3, 0, 3, 5)},
+		{pos(1, 5), "```monkey\nlet answer = 42;\n```", rng(1, 4, 1, 10)},
+	}
+
+	for _, tt := range tests {
+		var hover *Hover
+		if err := c.call("textDocument/hover", TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     tt.pos,
+		}, &hover); err != nil {
+			t.Fatalf("hover: %v", err)
+		}
+		if hover == nil {
+			t.Errorf("hover at %+v returned null", tt.pos)
+			continue
+		}
+		if hover.Contents.Value != tt.value || hover.Range != tt.rng {
+			t.Errorf("hover at %+v = %+v, want %q %+v", tt.pos, hover, tt.value, tt.rng)
+		}
+	}
+
+	// 数値やメンバー名の上ではnull
+	for _, p := range []Position{pos(1, 14), pos(2, 27)} {
+		var hover *Hover
+		if err := c.call("textDocument/hover", TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     p,
+		}, &hover); err != nil {
+			t.Fatalf("hover: %v", err)
+		}
+		if hover != nil {
+			t.Errorf("hover at %+v = %+v, want null", p, hover)
+		}
+	}
+
+	c.shutdown()
+}
+
+func TestDefinition(t *testing.T) {
+	c := newTestClient(t)
+	uri := "file:///nav.mk"
+	c.open(uri, navigationSource)
+
+	tests := []struct {
+		pos  Position
+		want *Location
+	}{
+		{pos(2, 14), &Location{URI: uri, Range: rng(1, 4, 1, 10)}},
+		{pos(2, 22), &Location{URI: uri, Range: rng(0, 19, 0, 22)}},
+		{pos(3, 2), &Location{URI: uri, Range: rng(2, 4, 2, 9)}},
+		{pos(1, 14), nil},
+	}
+
+	for _, tt := range tests {
+		var loc *Location
+		if err := c.call("textDocument/definition", TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     tt.pos,
+		}, &loc); err != nil {
+			t.Fatalf("definition: %v", err)
+		}
+		if !reflect.DeepEqual(loc, tt.want) {
+			t.Errorf("definition at %+v = %+v, want %+v", tt.pos, loc, tt.want)
+		}
+	}
+
+	c.shutdown()
+}
+
+func TestDocumentSymbol(t *testing.T) {
+	c := newTestClient(t)
+	uri := "file:///nav.mk"
+	c.open(uri, navigationSource)
+
+	var symbols []DocumentSymbol
+	if err := c.call("textDocument/documentSymbol", DocumentSymbolParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+	}, &symbols); err != nil {
+		t.Fatalf("documentSymbol: %v", err)
+	}
+
+	want := []DocumentSymbol{
+		{Name: "lib", Detail: `import "lib.mk" as lib;`, Kind: symbolKindModule, Range: rng(0, 0, 0, 23), SelectionRange: rng(0, 19, 0, 22)},
+		{Name: "answer", Detail: "let answer = 42;", Kind: symbolKindVariable, Range: rng(1, 0, 1, 16), SelectionRange: rng(1, 4, 1, 10)},
+		{Name: "total", Detail: "let total = answer * lib.Scale;", Kind: symbolKindVariable, Range: rng(2, 0, 2, 31), SelectionRange: rng(2, 4, 2, 9)},
+	}
+	if !reflect.DeepEqual(symbols, want) {
+		t.Errorf("documentSymbol = %+v, want %+v", symbols, want)
+	}
+
+	c.shutdown()
+}
+
+func TestFormatting(t *testing.T) {
+	c := newTestClient(t)
+	uri := "file:///fmt.mk"
+	c.open(uri, "let  a=1 ;let b =-a*2\n\n\n\nb")
+
+	var edits []TextEdit
+	if err := c.call("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+	}, &edits); err != nil {
+		t.Fatalf("formatting: %v", err)
+	}
+	want := []TextEdit{{Range: rng(0, 0, 4, 1), NewText: "let a = 1;\nlet b = -a * 2;\n\nb;\n"}}
+	if !reflect.DeepEqual(edits, want) {
+		t.Errorf("formatting = %+v, want %+v", edits, want)
+	}
+
+	// 構文エラーがあれば何も変更しない
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "let a ="}},
+	})
+	c.diagnostics()
+	if err := c.call("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+	}, &edits); err != nil {
+		t.Fatalf("formatting: %v", err)
+	}
+	if len(edits) != 0 {
+		t.Errorf("formatting with syntax errors = %+v, want none", edits)
+	}
+
+	c.shutdown()
+}
+
+func TestPositionUTF16(t *testing.T) {
+	d := newDocument("file:///utf16.mk", 1, "import \"😀é\" as m;\nm;")
+
+	// 😀はUTF-16で2単位、éは1単位
+	if got := d.positionOf(19); got != pos(0, 16) {
+		t.Errorf("positionOf(19) = %+v, want %+v", got, pos(0, 16))
+	}
+	if got := d.offsetOf(pos(0, 16)); got != 19 {
+		t.Errorf("offsetOf(0:16) = %d, want 19", got)
+	}
+	if got := d.offsetOf(pos(0, 100)); got != 21 {
+		t.Errorf("offsetOf(0:100) = %d, want 21", got)
+	}
+	if r := d.referenceAt(pos(0, 16)); r == nil || r.Ident.Value != "m" {
+		t.Errorf("referenceAt(0:16) = %+v, want m", r)
+	}
+}
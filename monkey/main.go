@@ -1,20 +1,95 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/user"
+	"sort"
 
 	"github.com/tMinamiii/various-parser/monkey/repl"
 )
 
+// 終了コード
+const (
+	exitOK     = 0
+	exitError  = 1 // 構文エラーや意味エラーなど、プログラムに問題がある
+	exitUsage  = 2 // 引数の誤りや読み書きの失敗
+	exitNoEval = 3 // プログラムは正しいが、評価器が無いので実行できない
+)
+
 func main() {
-	user, err := user.Current()
-	if err != nil {
-		panic(err)
+	os.Exit(cli(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// cli サブコマンドを実行して終了コードを返す。引数が無ければREPLを始める
+func cli(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		greet(stdout)
+		repl.StartREPL(stdin, stdout)
+		return exitOK
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stdout)
+		return exitOK
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "monkey: unknown command %q\n", args[0])
+		usage(stderr)
+		return exitUsage
+	}
+
+	err := cmd.run(args[1:], stdin, stdout, stderr)
+	var perr *programError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		// -hの使い方はFlagSetがstderrに出している
+		return exitOK
+	case errors.Is(err, errNoEvaluator):
+		fmt.Fprintf(stderr, "monkey %s: %s\n", args[0], err)
+		return exitNoEval
+	case errors.As(err, &perr):
+		for _, e := range perr.errors {
+			fmt.Fprintf(stderr, "%s:%s\n", perr.name, e)
+		}
+		return exitError
+	default:
+		fmt.Fprintf(stderr, "monkey %s: %s\n", args[0], err)
+		return exitUsage
+	}
+}
+
+// greet REPLの挨拶。ユーザー名が引けなくても止まらない
+func greet(out io.Writer) {
+	if u, err := user.Current(); err == nil {
+		fmt.Fprintf(out, "Hello %s! This is the Monkey programming language!\n", u.Username)
+	} else {
+		fmt.Fprintf(out, "Hello! This is the Monkey programming language!\n")
+	}
+	fmt.Fprintf(out, "Feel free to type in commands\n")
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: monkey [command] [arguments]")
+	fmt.Fprintln(w, "\nWith no command, monkey starts the REPL. The commands are:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-6s %s\n", name, commands[name].summary)
 	}
 
-	fmt.Printf("Hello %s! This is the Monkey programming language!\n", user.Username)
-	fmt.Printf("Feel free to type in commands\n")
-	repl.StartREPL(os.Stdin, os.Stdout)
+	fmt.Fprintln(w, "\nExit status:")
+	fmt.Fprintf(w, "  %d  success\n", exitOK)
+	fmt.Fprintf(w, "  %d  the program has syntax or semantic errors\n", exitError)
+	fmt.Fprintf(w, "  %d  wrong arguments, or a file could not be read or written\n", exitUsage)
+	fmt.Fprintf(w, "  %d  run only: the program is valid but cannot be run without an evaluator\n", exitNoEval)
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLI(t *testing.T) {
+	tests := []struct {
+		args   []string
+		stdin  string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"lex"}, "let x = 5;", exitOK, "1:1\tLET\t\"let\"\n1:5\tIDENT\t\"x\"\n1:7\t=\t\"=\"\n1:9\tINT\t\"5\"\n1:10\t;\t\";\"\n", ""},
+		{[]string{"lex", "-"}, "x \"ab", exitError, "1:1\tIDENT\t\"x\"\n1:3\tILLEGAL\t\"\\\"ab\"\n", "<stdin>:1:3: unterminated string\n"},
+		{[]string{"lex", "-"}, "x @", exitError, "1:1\tIDENT\t\"x\"\n1:3\tILLEGAL\t\"@\"\n", "<stdin>:1:3: illegal character \"@\"\n"},
+		{[]string{"parse"}, "let x = 1 + 2 * 3;\nreturn x;", exitOK, "let x = (1 + (2 * 3));\nreturn x;\n", ""},
+		{[]string{"parse"}, "let = 1;", exitError, "", "<stdin>:1:5: expected next token to be IDENT, got = instead\n<stdin>:1:5: no prefix parse function for = found\n"},
+		{[]string{"fmt"}, "let x=1+2;x", exitOK, "let x = 1 + 2;\nx;\n", ""},
+		{[]string{"fmt", "-w"}, "x", exitUsage, "", "monkey fmt: -w needs a file\n"},
+		{[]string{"check"}, "let x = 1;\nlet x = y;", exitError, "", "<stdin>:2:5: x redeclared\n<stdin>:2:9: undefined: y\n"},
+		{[]string{"check"}, "let x = 1;\nx;", exitOK, "", ""},
+		{[]string{"run"}, "let x = y;", exitError, "", "<stdin>:1:9: undefined: y\n"},
+		{[]string{"run"}, "let x = 1;", exitNoEval, "", "monkey run: cannot run: the evaluator is not implemented yet\n"},
+		{[]string{"ast", "--format=sexpr"}, "1", exitOK, "(Program 1:1 (ExpressionStatement 1:1 (IntegerLiteral 1:1 1)))\n", ""},
+		{[]string{"ast", "--format=xml"}, "1", exitUsage, "", "monkey ast: unknown format \"xml\"\n"},
+		{[]string{"lex", "a.mk", "b.mk"}, "", exitUsage, "", "monkey lex: too many arguments\n"},
+		{[]string{"lex", "does-not-exist.mk"}, "", exitUsage, "", "monkey lex: open does-not-exist.mk: no such file or directory\n"},
+	}
+
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := cli(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+		if code != tt.code {
+			t.Errorf("%v: exit code wrong. got=%d, want=%d", tt.args, code, tt.code)
+		}
+		if stdout.String() != tt.stdout {
+			t.Errorf("%v: stdout wrong. got=%q, want=%q", tt.args, stdout.String(), tt.stdout)
+		}
+		if stderr.String() != tt.stderr {
+			t.Errorf("%v: stderr wrong. got=%q, want=%q", tt.args, stderr.String(), tt.stderr)
+		}
+	}
+}
+
+func TestCLIUnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := cli([]string{"build"}, strings.NewReader(""), &stdout, &stderr)
+	if code != exitUsage {
+		t.Errorf("exit code wrong. got=%d, want=%d", code, exitUsage)
+	}
+	if !strings.HasPrefix(stderr.String(), "monkey: unknown command \"build\"\nusage: monkey") {
+		t.Errorf("stderr wrong. got=%q", stderr.String())
+	}
+}
+
+func TestCLIFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.mk")
+	if err := os.WriteFile(file, []byte("let x=1;\n// done\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := cli([]string{"fmt", "-w", file}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Fatalf("exit code wrong. got=%d, stderr=%q", code, stderr.String())
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "let x = 1;\n// done\n" {
+		t.Errorf("file wrong. got=%q", string(b))
+	}
+
+	stderr.Reset()
+	if code := cli([]string{"check", file}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Errorf("exit code wrong. got=%d, stderr=%q", code, stderr.String())
+	}
+}
+
+func TestCLIREPL(t *testing.T) {
+	t.Setenv("MONKEYRC", filepath.Join(t.TempDir(), "monkeyrc"))
+
+	var stdout, stderr bytes.Buffer
+	if code := cli(nil, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Errorf("exit code wrong. got=%d", code)
+	}
+	if !strings.Contains(stdout.String(), "This is the Monkey programming language!") {
+		t.Errorf("greeting wrong. got=%q", stdout.String())
+	}
+}
+
+func TestCLIFlagOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := cli([]string{"ast", "-h"}, strings.NewReader(""), &stdout, &stderr); code != exitOK {
+		t.Errorf("exit code wrong. got=%d, want=%d", code, exitOK)
+	}
+	if stdout.Len() != 0 || !strings.Contains(stderr.String(), "-format") {
+		t.Errorf("-h should print the flags to stderr. stdout=%q, stderr=%q", stdout.String(), stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := cli([]string{"lex", "-x"}, strings.NewReader(""), &stdout, &stderr); code != exitUsage {
+		t.Errorf("exit code wrong. got=%d, want=%d", code, exitUsage)
+	}
+	if stdout.Len() != 0 || !strings.HasPrefix(stderr.String(), "flag provided but not defined: -x\n") {
+		t.Errorf("flag errors should go to stderr. stdout=%q, stderr=%q", stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,167 @@
+package module
+
+// import文で読み込まれるファイルを解決する。
+// * パスはimportしたファイルのディレクトリからの相対パスとして解決する
+// * 一度読み込んだファイルはキャッシュし、同じパスを何度importしても構文解析は1回だけ
+// * 解決中のファイルをスタックに積んでおき、循環importを検出する
+// ファイルの読み込みはfs.FSを通すので、テストではfstest.MapFSを使える。
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/parser"
+)
+
+// Module 1ファイル分の構文解析結果
+type Module struct {
+	Path    string
+	Program *ast.Program
+	Imports map[string]*Module           // import文の別名 -> モジュール
+	Exports map[string]*ast.LetStatement // 公開されたトップレベルのlet束縛
+}
+
+// IsExported Goと同じく、大文字で始まる名前だけが他のモジュールから見える
+func IsExported(name string) bool {
+	for _, r := range name {
+		return unicode.IsUpper(r)
+	}
+	return false
+}
+
+type Resolver struct {
+	fsys    fs.FS
+	cache   map[string]*Module
+	loading []string // 解決中のパス。循環importの検出に使う
+}
+
+func NewResolver(fsys fs.FS) *Resolver {
+	return &Resolver{
+		fsys:  fsys,
+		cache: make(map[string]*Module),
+	}
+}
+
+// CycleError 循環importが見つかった場合のエラー
+type CycleError struct {
+	Paths []string // 循環しているパス。最初と最後は同じパスになる
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("import cycle: %s", strings.Join(e.Paths, " -> "))
+}
+
+// Resolve nameのファイルとそこからimportされるファイルを全て読み込む
+func (r *Resolver) Resolve(name string) (*Module, error) {
+	return r.resolve(path.Clean(name))
+}
+
+func (r *Resolver) resolve(name string) (*Module, error) {
+	if m, ok := r.cache[name]; ok {
+		return m, nil
+	}
+
+	for i, loading := range r.loading {
+		if loading == name {
+			paths := append([]string{}, r.loading[i:]...)
+			return nil, &CycleError{Paths: append(paths, name)}
+		}
+	}
+
+	r.loading = append(r.loading, name)
+	defer func() { r.loading = r.loading[:len(r.loading)-1] }()
+
+	m, err := r.load(name)
+	if err != nil {
+		return nil, err
+	}
+	r.cache[name] = m
+	return m, nil
+}
+
+func (r *Resolver) load(name string) (*Module, error) {
+	src, err := fs.ReadFile(r.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.NewParser(lexer.NewLexer(string(src)))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) > 0 {
+		return nil, fmt.Errorf("%s: %s", name, strings.Join(errs, "; "))
+	}
+
+	m := &Module{
+		Path:    name,
+		Program: program,
+		Imports: make(map[string]*Module),
+		Exports: make(map[string]*ast.LetStatement),
+	}
+
+	for _, s := range program.Statements {
+		switch stmt := s.(type) {
+		case *ast.ImportStatement:
+			if _, ok := m.Imports[stmt.Alias.Value]; ok {
+				return nil, fmt.Errorf("%s: %s redeclared in this module", name, stmt.Alias.Value)
+			}
+			target, err := r.importPath(name, stmt.Path)
+			if err != nil {
+				return nil, err
+			}
+			imported, err := r.resolve(target)
+			if err != nil {
+				return nil, err
+			}
+			m.Imports[stmt.Alias.Value] = imported
+		case *ast.LetStatement:
+			if IsExported(stmt.Name.Value) {
+				m.Exports[stmt.Name.Value] = stmt
+			}
+		}
+	}
+
+	if err := checkMembers(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// importPath importしたファイルのディレクトリを起点にパスを解決する
+func (r *Resolver) importPath(importer, spec string) (string, error) {
+	target := path.Join(path.Dir(importer), spec)
+	if !fs.ValidPath(target) {
+		return "", fmt.Errorf("%s: invalid import path %q", importer, spec)
+	}
+	return target, nil
+}
+
+// checkMembers m.nameのnameがimportしたモジュールから公開されているか確認する
+func checkMembers(m *Module) error {
+	var err error
+	ast.Inspect(m.Program, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		me, ok := n.(*ast.MemberExpression)
+		if !ok {
+			return true
+		}
+		obj, ok := me.Object.(*ast.Identifier)
+		if !ok {
+			return true
+		}
+		imported, ok := m.Imports[obj.Value]
+		if !ok {
+			return true
+		}
+		if _, ok := imported.Exports[me.Member.Value]; !ok {
+			err = fmt.Errorf("%s: %s is not exported by %s", m.Path, me.String(), imported.Path)
+		}
+		return true
+	})
+	return err
+}
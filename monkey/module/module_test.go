@@ -0,0 +1,123 @@
+package module
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolve(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.mk":          {Data: []byte(`import "lib/math.mk" as math; import "lib/util.mk" as util; let x = math.Add + util.One;`)},
+		"lib/math.mk":      {Data: []byte(`import "util.mk" as util; let Add = util.One; let helper = 1;`)},
+		"lib/util.mk":      {Data: []byte(`let One = 1;`)},
+		"lib/unused.mk":    {Data: []byte(`let Unused = 1;`)},
+		"lib/nested/a.mk":  {Data: []byte(`import "../util.mk" as util; let A = util.One;`)},
+		"lib/nested/b.mk":  {Data: []byte(`import "a.mk" as a; let B = a.A;`)},
+		"lib/nested/c.mk":  {Data: []byte(`import "../../lib/nested/b.mk" as b; let C = b.B;`)},
+		"lib/nested/x.mk":  {Data: []byte(`import "./c.mk" as c;`)},
+		"lib/nested/y.mk":  {Data: []byte(`import "c.mk" as c; import "x.mk" as x;`)},
+		"lib/nested/z.mk":  {Data: []byte(`import "y.mk" as y;`)},
+		"lib/nested/zz.mk": {Data: []byte(``)},
+	}
+
+	r := NewResolver(fsys)
+	m, err := r.Resolve("main.mk")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+
+	math, ok := m.Imports["math"]
+	if !ok {
+		t.Fatalf("main.mk does not import math")
+	}
+	if math.Path != "lib/math.mk" {
+		t.Errorf("math.Path not %q. got=%q", "lib/math.mk", math.Path)
+	}
+	if _, ok := math.Exports["Add"]; !ok {
+		t.Errorf("math does not export Add")
+	}
+	if _, ok := math.Exports["helper"]; ok {
+		t.Errorf("math exports unexported helper")
+	}
+
+	// lib/math.mkとmain.mkは同じlib/util.mkを共有する
+	if m.Imports["util"] != math.Imports["util"] {
+		t.Errorf("lib/util.mk was loaded twice")
+	}
+
+	z, err := r.Resolve("lib/nested/z.mk")
+	if err != nil {
+		t.Fatalf("Resolve returned error: %v", err)
+	}
+	c := z.Imports["y"].Imports["c"]
+	if c.Path != "lib/nested/c.mk" {
+		t.Errorf("c.Path not %q. got=%q", "lib/nested/c.mk", c.Path)
+	}
+	if c != z.Imports["y"].Imports["x"].Imports["c"] {
+		t.Errorf("lib/nested/c.mk was loaded twice")
+	}
+	if c.Imports["b"].Imports["a"].Imports["util"] != m.Imports["util"] {
+		t.Errorf("lib/util.mk was loaded twice")
+	}
+
+	if _, err := r.Resolve("lib/nested/zz.mk"); err != nil {
+		t.Errorf("Resolve of empty module returned error: %v", err)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.mk":           {Data: []byte(`import "b.mk" as b;`)},
+		"b.mk":           {Data: []byte(`import "c.mk" as c;`)},
+		"c.mk":           {Data: []byte(`import "a.mk" as a;`)},
+		"self.mk":        {Data: []byte(`import "self.mk" as self;`)},
+		"private.mk":     {Data: []byte(`import "lib.mk" as lib; lib.secret;`)},
+		"lib.mk":         {Data: []byte(`let secret = 1; let Public = 2;`)},
+		"missing.mk":     {Data: []byte(`import "nothing.mk" as n;`)},
+		"escape.mk":      {Data: []byte(`import "../outside.mk" as o;`)},
+		"duplicate.mk":   {Data: []byte(`import "lib.mk" as lib; import "lib.mk" as lib;`)},
+		"syntax.mk":      {Data: []byte(`let = 1;`)},
+		"badimport.mk":   {Data: []byte(`import "syntax.mk" as s;`)},
+		"undefined.mk":   {Data: []byte(`import "lib.mk" as lib; let x = lib.Missing;`)},
+		"notimported.mk": {Data: []byte(`other.secret;`)},
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"a.mk", "import cycle: a.mk -> b.mk -> c.mk -> a.mk"},
+		{"self.mk", "import cycle: self.mk -> self.mk"},
+		{"private.mk", "private.mk: lib.secret is not exported by lib.mk"},
+		{"undefined.mk", "undefined.mk: lib.Missing is not exported by lib.mk"},
+		{"missing.mk", "open nothing.mk: file does not exist"},
+		{"escape.mk", `escape.mk: invalid import path "../outside.mk"`},
+		{"duplicate.mk", "duplicate.mk: lib redeclared in this module"},
+		{"badimport.mk", "syntax.mk: expected next token to be IDENT, got = instead; no prefix parse function for = found"},
+	}
+
+	for _, tt := range tests {
+		_, err := NewResolver(fsys).Resolve(tt.name)
+		if err == nil {
+			t.Errorf("%s: expected error", tt.name)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%s: expected error %q, got=%q", tt.name, tt.expected, err.Error())
+		}
+	}
+
+	_, err := NewResolver(fsys).Resolve("a.mk")
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("err is not *CycleError. got=%T", err)
+	}
+	if len(cycle.Paths) != 4 {
+		t.Errorf("cycle.Paths does not contain 4 paths. got=%v", cycle.Paths)
+	}
+
+	if _, err := NewResolver(fsys).Resolve("notimported.mk"); err != nil {
+		t.Errorf("member access on a non-module returned error: %v", err)
+	}
+}
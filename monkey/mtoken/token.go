@@ -1,19 +1,41 @@
 package mtoken
 
+import (
+	"fmt"
+	"sort"
+)
+
 type TokenType string
 
 type Token struct {
-	Type    TokenType
-	Literal string
+	Type    TokenType `json:"type"`
+	Literal string    `json:"literal"`
+	Pos     Position  `json:"pos"` // トークンの先頭の位置
+}
+
+// Position ソースコード上の位置。LineとColumnは1始まりで、Columnはバイト単位
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
 
+	// トリビア。NextTokenは読み飛ばし、lexer.Tokenizeだけが返す
+	WHITESPACE = "WHITESPACE"
+	COMMENT    = "COMMENT" // 「//」から行末まで
+
 	// 識別子 + リテラル
-	IDENT = "IDENT" // add, foobar, x, y ...
-	INT   = "INT"   // 1341412
+	IDENT  = "IDENT"  // add, foobar, x, y ...
+	INT    = "INT"    // 1341412
+	STRING = "STRING" // "foo/bar.mk"
 
 	// 演算子
 	ASSIGN   = "="
@@ -29,6 +51,7 @@ const (
 	// デリミタ
 	COMMA     = ","
 	SEMICOLON = ";"
+	DOT       = "."
 
 	L_PAREN = "("
 	R_PAREN = ")"
@@ -43,6 +66,8 @@ const (
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	IMPORT   = "IMPORT"
+	AS       = "AS"
 
 	EQ     = "=="
 	NOT_EQ = "!="
@@ -56,6 +81,28 @@ var keywords = map[string]TokenType{
 	"if":     IF,
 	"else":   ELSE,
 	"return": RETURN,
+	"import": IMPORT,
+	"as":     AS,
+}
+
+// IsKeyword tがキーワードのトークンタイプかどうか
+func IsKeyword(t TokenType) bool {
+	for _, kw := range keywords {
+		if kw == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Keywords キーワードの綴りを辞書順に並べたもの
+func Keywords() []string {
+	words := make([]string, 0, len(keywords))
+	for word := range keywords {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
 }
 
 func LookupIdent(ident string) TokenType {
@@ -0,0 +1,192 @@
+package parser
+
+// エディタでキーを打つたびにファイル全体を構文解析し直さないための増分構文解析。
+// * 文の境界では構文解析器の状態はcurTokenだけなので、同じトークンから始めれば同じ文が得られる
+// * 編集位置の1つ前の文から字句解析と構文解析をやり直す(前の文は次の文の先頭のトークンを先読みしている)
+// * 編集範囲より後ろで、古い文の先頭と同じ位置から文を読み始めたら残りは古い文を使い回す
+// * 使い回す文は位置情報だけずらす
+import (
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+// Edit ソースの[Start, End)をTextで置き換える変更。位置はバイトオフセット
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Document 増分構文解析のために、ソースとASTに加えて文ごとの開始位置と構文エラーを持つ
+type Document struct {
+	Source  string
+	Program *ast.Program
+
+	stmts []stmtInfo // Program.Statementsと同じ順
+}
+
+type stmtInfo struct {
+	pos    mtoken.Position // 文の最初のトークンの位置
+	errors []*ParseError
+}
+
+// ParseDocument srcを全て構文解析する
+func ParseDocument(src string) *Document {
+	d := &Document{Source: src, Program: &ast.Program{Statements: []ast.Statement{}}}
+	p := NewParser(lexer.NewLexer(src))
+	d.parse(p, nil)
+	return d
+}
+
+// Errors 全ての文の構文エラー。ParseProgramのParseErrorsと同じ順になる
+func (d *Document) Errors() []*ParseError {
+	errors := []*ParseError{}
+	for _, s := range d.stmts {
+		errors = append(errors, s.errors...)
+	}
+	return errors
+}
+
+// parse EOFまで文を読む。syncがtrueを返したらそこで止める
+func (d *Document) parse(p *Parser, sync func(offset int) bool) {
+	for p.curToken.Type != mtoken.EOF {
+		if sync != nil && sync(p.curToken.Pos.Offset) {
+			return
+		}
+
+		pos := p.curToken.Pos
+		errs := len(p.errors)
+		stmt := p.parseStatement()
+		d.Program.Statements = append(d.Program.Statements, stmt)
+		d.stmts = append(d.stmts, stmtInfo{pos: pos, errors: p.errors[errs:]})
+		p.nextToken()
+	}
+}
+
+// Reparse prevのソースにeditを適用したDocumentを返す。
+// 結果はParseDocumentで全て構文解析し直したものと同じになる。
+// 変更のない文のASTはprevと共有し位置を書き換えるので、以後prevは使えない。
+func Reparse(prev *Document, edit Edit) *Document {
+	src := prev.Source[:edit.Start] + edit.Text + prev.Source[edit.End:]
+	delta := len(edit.Text) - (edit.End - edit.Start)
+
+	// 編集位置を含む文の1つ前の文からやり直す
+	owner := -1
+	for i, s := range prev.stmts {
+		if s.pos.Offset <= edit.Start {
+			owner = i
+		}
+	}
+	restart := owner - 1
+	if restart < 0 {
+		return ParseDocument(src)
+	}
+	base := prev.stmts[restart].pos
+
+	d := &Document{
+		Source:  src,
+		Program: &ast.Program{Statements: append([]ast.Statement{}, prev.Program.Statements[:restart]...)},
+		stmts:   append([]stmtInfo{}, prev.stmts[:restart]...),
+	}
+
+	// 古い文の先頭位置からその文の番号を引けるようにしておく
+	starts := make(map[int]int)
+	for i := restart; i < len(prev.stmts); i++ {
+		if prev.stmts[i].pos.Offset >= edit.End {
+			starts[prev.stmts[i].pos.Offset] = i
+		}
+	}
+
+	reuse := -1
+	newEnd := edit.Start + len(edit.Text)
+	p := NewParser(lexer.NewLexerAt(src, base))
+	d.parse(p, func(offset int) bool {
+		if offset < newEnd {
+			return false
+		}
+		if i, ok := starts[offset-delta]; ok {
+			reuse = i
+			return true
+		}
+		return false
+	})
+
+	if reuse >= 0 {
+		s := shifter{
+			delta:   delta,
+			oldLine: positionAt(prev.Source, base, edit.End),
+			newLine: positionAt(src, base, newEnd),
+		}
+		for i := reuse; i < len(prev.stmts); i++ {
+			stmt := prev.Program.Statements[i]
+			s.shiftNode(stmt)
+			info := stmtInfo{pos: s.shift(prev.stmts[i].pos)}
+			for _, e := range prev.stmts[i].errors {
+				info.errors = append(info.errors, &ParseError{Pos: s.shift(e.Pos), Msg: e.Msg})
+			}
+			d.Program.Statements = append(d.Program.Statements, stmt)
+			d.stmts = append(d.stmts, info)
+		}
+	}
+
+	return d
+}
+
+// positionAt baseより後ろにあるoffsetの位置を、baseから数えて求める
+func positionAt(src string, base mtoken.Position, offset int) mtoken.Position {
+	pos := base
+	for i := base.Offset; i < offset; i++ {
+		if src[i] == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	pos.Offset = offset
+	return pos
+}
+
+// shifter 編集範囲より後ろの位置をずらす。
+// 編集範囲の終わりと同じ行にあるものは列も変わり、それより後ろの行は行番号だけが変わる。
+type shifter struct {
+	delta   int
+	oldLine mtoken.Position // 古いソースでの編集範囲の終わり
+	newLine mtoken.Position // 新しいソースでの編集範囲の終わり
+}
+
+func (s shifter) shift(pos mtoken.Position) mtoken.Position {
+	if pos.Line == s.oldLine.Line {
+		pos.Column += s.newLine.Column - s.oldLine.Column
+	}
+	pos.Line += s.newLine.Line - s.oldLine.Line
+	pos.Offset += s.delta
+	return pos
+}
+
+func (s shifter) shiftNode(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.LetStatement:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		case *ast.ReturnStatement:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		case *ast.ExpressionStatement:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		case *ast.ImportStatement:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		case *ast.Identifier:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		case *ast.IntegerLiteral:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		case *ast.PrefixExpression:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		case *ast.InfixExpression:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		case *ast.MemberExpression:
+			n.Token.Pos = s.shift(n.Token.Pos)
+		}
+		return true
+	})
+}
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+)
+
+const incrementalSource = `import "lib/math" as m;
+let x = 5;
+let y = x + 10 * 2;
+// コメント
+return m.pi;
+-y < x == !z
+let = 3;
+let w = 1 +
+2;
+`
+
+func TestParseDocument(t *testing.T) {
+	d := ParseDocument(incrementalSource)
+
+	p := NewParser(lexer.NewLexer(incrementalSource))
+	program := p.ParseProgram()
+	if !reflect.DeepEqual(d.Program, program) {
+		t.Errorf("program wrong. got=%q, want=%q", d.Program.String(), program.String())
+	}
+	if !reflect.DeepEqual(d.Errors(), p.ParseErrors()) {
+		t.Errorf("errors wrong. got=%v, want=%v", d.Errors(), p.ParseErrors())
+	}
+}
+
+func TestReparse(t *testing.T) {
+	tests := []struct {
+		name string
+		edit Edit
+	}{
+		{"insert statement", Edit{Start: 24, End: 24, Text: "let a = 1;\n"}},
+		{"change literal", Edit{Start: 32, End: 33, Text: "500"}},
+		{"delete statement", Edit{Start: 35, End: 55, Text: ""}},
+		{"join lines", Edit{Start: 33, End: 35, Text: " "}},
+		{"break statement", Edit{Start: 37, End: 38, Text: ""}},
+		{"add operator", Edit{Start: 55, End: 55, Text: "+ "}},
+		{"edit comment", Edit{Start: 58, End: 60, Text: "\n\n"}},
+		{"fix error", Edit{Start: 101, End: 101, Text: "v "}},
+		{"edit last", Edit{Start: len(incrementalSource) - 3, End: len(incrementalSource), Text: "3"}},
+		{"replace all", Edit{Start: 0, End: len(incrementalSource), Text: "let b = 2"}},
+	}
+
+	for _, tt := range tests {
+		d := Reparse(ParseDocument(incrementalSource), tt.edit)
+		want := ParseDocument(d.Source)
+		if !reflect.DeepEqual(d.Program, want.Program) {
+			t.Errorf("%s: program wrong.\ngot=%q\nwant=%q", tt.name, d.Program.String(), want.Program.String())
+		}
+		if !reflect.DeepEqual(d.Errors(), want.Errors()) {
+			t.Errorf("%s: errors wrong.\ngot=%v\nwant=%v", tt.name, d.Errors(), want.Errors())
+		}
+	}
+}
+
+func TestReparseRandom(t *testing.T) {
+	fragments := []string{"", " ", "\n", ";", "let ", "return ", "x", "10", "+", "* 2", ".", "=", "!", "// c\n", "let q = 1;\n"}
+	r := rand.New(rand.NewSource(1))
+
+	d := ParseDocument(incrementalSource)
+	for i := 0; i < 2000; i++ {
+		start := r.Intn(len(d.Source) + 1)
+		end := start + r.Intn(len(d.Source)-start+1)
+		if end-start > 8 {
+			end = start + r.Intn(8)
+		}
+		edit := Edit{Start: start, End: end, Text: fragments[r.Intn(len(fragments))]}
+
+		before := d.Source
+		d = Reparse(d, edit)
+		want := ParseDocument(d.Source)
+		if !reflect.DeepEqual(d.Program, want.Program) || !reflect.DeepEqual(d.Errors(), want.Errors()) {
+			t.Fatalf("reparse differs from full parse.\nsource=%q\nedit=%+v\ngot=%q %v\nwant=%q %v",
+				before, edit, d.Program.String(), d.Errors(), want.Program.String(), want.Errors())
+		}
+		if len(d.Source) > 400 {
+			d = ParseDocument(incrementalSource)
+		}
+	}
+}
+
+func TestReparseReusesStatements(t *testing.T) {
+	prev := ParseDocument(incrementalSource)
+	old := append(prev.Program.Statements[:0:0], prev.Program.Statements...)
+
+	// 2つ目の文の5を500にする。1つ目の文からやり直すので、使い回すのは3つ目以降
+	d := Reparse(prev, Edit{Start: 32, End: 33, Text: "500"})
+
+	if len(d.Program.Statements) != len(old) {
+		t.Fatalf("statements wrong. got=%d, want=%d", len(d.Program.Statements), len(old))
+	}
+	for i, stmt := range d.Program.Statements {
+		reused := stmt == old[i]
+		if want := i >= 2; reused != want {
+			t.Errorf("statement %d reused=%t, want=%t", i, reused, want)
+		}
+	}
+}
@@ -25,6 +25,7 @@ const (
 	PRODUCT     // *
 	PREFIX      // -X または !X
 	CALL        // myFunction(X)
+	MEMBER      // m.name
 )
 
 type (
@@ -42,6 +43,7 @@ var precedence = map[mtoken.TokenType]int{
 	mtoken.MINUS:    SUM,
 	mtoken.SLASH:    PRODUCT,
 	mtoken.ASTERISK: PRODUCT,
+	mtoken.DOT:      MEMBER,
 }
 
 // 5 + 5 * 10のように、「+」の後に別の演算子式が続く可能性があ
@@ -50,7 +52,7 @@ var precedence = map[mtoken.TokenType]int{
 type Parser struct {
 	l *lexer.Lexer
 
-	errors    []string
+	errors    []*ParseError
 	curToken  mtoken.Token
 	peekToken mtoken.Token
 
@@ -61,7 +63,7 @@ type Parser struct {
 func NewParser(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []*ParseError{},
 	}
 
 	// マップの初期化し構文解析器を登録する
@@ -80,6 +82,7 @@ func NewParser(l *lexer.Lexer) *Parser {
 	p.registerInfix(mtoken.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(mtoken.LT, p.parseInfixExpression)
 	p.registerInfix(mtoken.GT, p.parseInfixExpression)
+	p.registerInfix(mtoken.DOT, p.parseMemberExpression)
 
 	// 2つトークンを読み込み。curTokenとpeekTokenの両方がセット
 	p.nextToken()
@@ -87,14 +90,41 @@ func NewParser(l *lexer.Lexer) *Parser {
 	return p
 }
 
+// ParseError 位置情報付きの構文エラー
+type ParseError struct {
+	Pos mtoken.Position
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
 func (p *Parser) Errors() []string {
+	msgs := make([]string, len(p.errors))
+	for i, e := range p.errors {
+		msgs[i] = e.Msg
+	}
+	return msgs
+}
+
+// ParseErrors エディタなどで位置を表示したい場合はこちらを使う
+func (p *Parser) ParseErrors() []*ParseError {
 	return p.errors
 }
 
+func (p *Parser) addError(pos mtoken.Position, msg string) {
+	p.errors = append(p.errors, &ParseError{Pos: pos, Msg: msg})
+}
+
 // 次のトークンが期待しているものでなければp.errorsにメッセージを詰める
 func (p *Parser) peekError(t mtoken.TokenType) {
+	if p.peekTokenIs(mtoken.ILLEGAL) {
+		p.addError(p.peekToken.Pos, lexer.IllegalMessage(p.peekToken))
+		return
+	}
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addError(p.peekToken.Pos, msg)
 }
 
 // 空白を飛ばしながら、次のトークンを探す
@@ -121,6 +151,8 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case mtoken.RETURN:
 		return p.parseReturnStatement()
+	case mtoken.IMPORT:
+		return p.parseImportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -129,9 +161,16 @@ func (p *Parser) parseStatement() ast.Statement {
 
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
+	// 値の無い「return;」も書ける
+	if p.peekTokenIs(mtoken.SEMICOLON) {
+		p.nextToken()
+		return stmt
+	}
 	p.nextToken()
-	//TODO: セミコロンに遭遇するまで式を読み飛ばしてしまっている
-	for !p.curTokenIs(mtoken.SEMICOLON) {
+
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(mtoken.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -148,9 +187,35 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	if !p.expectPeek(mtoken.ASSIGN) {
 		return nil
 	}
+	p.nextToken()
+
+	// モジュールのメンバーアクセスなど、右辺の式も解析しておく
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(mtoken.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// import "path/to/mod.mk" as m;
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+	if !p.expectPeek(mtoken.STRING) {
+		return nil
+	}
+	stmt.Path = p.curToken.Literal
 
-	//TODO: セミコロンに遭遇するまで式を読み飛ばしてしまっている
-	for !p.curTokenIs(mtoken.SEMICOLON) {
+	if !p.expectPeek(mtoken.AS) {
+		return nil
+	}
+	if !p.expectPeek(mtoken.IDENT) {
+		return nil
+	}
+	stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(mtoken.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -198,8 +263,12 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	return stmt
 }
 func (p *Parser) noPrefixParseFnError(t mtoken.TokenType) {
+	if t == mtoken.ILLEGAL {
+		p.addError(p.curToken.Pos, lexer.IllegalMessage(p.curToken))
+		return
+	}
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.addError(p.curToken.Pos, msg)
 }
 
 // p.curToken.Typeの前置に関連付けられた構文解析関数があるかを確認している
@@ -244,7 +313,7 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	if err != nil {
 		// int64に変換できない場合
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken.Pos, msg)
 		return nil
 	}
 	lit.Value = value
@@ -265,6 +334,14 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	return expression
 }
 
+// Precedence トークンタイプの優先順位を返す。フォーマッタが括弧の要否を判断するのに使う
+func Precedence(t mtoken.TokenType) int {
+	if p, ok := precedence[t]; ok {
+		return p
+	}
+	return LOWEST
+}
+
 func (p *Parser) peekPrecedence() int {
 	if p, ok := precedence[p.peekToken.Type]; ok {
 		return p
@@ -296,3 +373,18 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	expression.Right = p.parseExpression(precedence)
 	return expression
 }
+
+// parseMemberExpression m.nameのように「.」の右側は必ず識別子になる
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	expression := &ast.MemberExpression{
+		Token:  p.curToken,
+		Object: left,
+	}
+
+	if !p.expectPeek(mtoken.IDENT) {
+		return nil
+	}
+	expression.Member = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return expression
+}
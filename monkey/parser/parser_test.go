@@ -57,6 +57,10 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"3 + 4 * 5 == 3 * 1 + 4 * 5",
 			"((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))",
 		},
+		{
+			"-m.a * m.b",
+			"((-m.a) * m.b)",
+		},
 	}
 	for _, tt := range tests {
 		l := lexer.NewLexer(tt.input)
@@ -271,6 +275,26 @@ return 993322;
 		}
 	}
 }
+func TestBareReturnStatement(t *testing.T) {
+	p := NewParser(lexer.NewLexer("return;\nreturn x;"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+	bare, ok := program.Statements[0].(*ast.ReturnStatement)
+	if !ok {
+		t.Fatalf("stmt not *ast.ReturnStatement. got=%T", program.Statements[0])
+	}
+	if bare.ReturnValue != nil {
+		t.Errorf("bare return has a value. got=%s", bare.ReturnValue)
+	}
+	if got := program.Statements[1].String(); got != "return x;" {
+		t.Errorf("next statement wrong. got=%q", got)
+	}
+}
+
 func TestLetStatements(t *testing.T) {
 	input := `
 let x = 5;
@@ -305,6 +329,93 @@ let = 838383;
 	}
 }
 
+func TestImportStatement(t *testing.T) {
+	input := `
+import "lib/math.mk" as math;
+let x = math.Add;
+`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ImportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ImportStatement. got=%T", program.Statements[0])
+	}
+	if stmt.Path != "lib/math.mk" {
+		t.Errorf("stmt.Path not %q. got=%q", "lib/math.mk", stmt.Path)
+	}
+	if stmt.Alias.Value != "math" {
+		t.Errorf("stmt.Alias.Value not %q. got=%q", "math", stmt.Alias.Value)
+	}
+
+	letStmt, ok := program.Statements[1].(*ast.LetStatement)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not ast.LetStatement. got=%T", program.Statements[1])
+	}
+	member, ok := letStmt.Value.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("letStmt.Value is not ast.MemberExpression. got=%T", letStmt.Value)
+	}
+	if member.String() != "math.Add" {
+		t.Errorf("member.String() not %q. got=%q", "math.Add", member.String())
+	}
+}
+
+func TestImportStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import math;`, "expected next token to be STRING, got IDENT instead"},
+		{`import "math.mk";`, "expected next token to be AS, got ; instead"},
+		{`m.1;`, "expected next token to be IDENT, got INT instead"},
+		{`import "a.mk as m;`, "unterminated string"},
+		{`let s = "a.mk;`, "unterminated string"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("%q: expected parser errors", tt.input)
+			continue
+		}
+		if errors[0] != tt.expected {
+			t.Errorf("%q: expected error %q, got=%q", tt.input, tt.expected, errors[0])
+		}
+	}
+}
+
+func TestParseErrorsPosition(t *testing.T) {
+	input := "let x = 1;\nlet = 2;\n99999999999999999999;"
+	p := NewParser(lexer.NewLexer(input))
+	p.ParseProgram()
+
+	expected := []string{
+		"2:5: expected next token to be IDENT, got = instead",
+		"2:5: no prefix parse function for = found",
+		`3:1: could not parse "99999999999999999999" as integer`,
+	}
+	errors := p.ParseErrors()
+	if len(errors) != len(expected) {
+		t.Fatalf("parser has %d errors, want %d: %v", len(errors), len(expected), p.Errors())
+	}
+	for i, e := range errors {
+		if e.Error() != expected[i] {
+			t.Errorf("errors[%d] = %q, want %q", i, e.Error(), expected[i])
+		}
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
@@ -0,0 +1,110 @@
+package refactor
+
+// CSTを使ったソースの書き換え。書き換えるのは対象のトークンの範囲だけなので、
+// 空白やコメント、書式はそのまま残る。
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tMinamiii/various-parser/monkey/checker"
+	"github.com/tMinamiii/various-parser/monkey/cst"
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+// Edit ソースの[Start, End)をTextで置き換える
+type Edit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Apply editsを適用する。editsは重なっていてはいけない
+func Apply(src string, edits []Edit) string {
+	sorted := append([]Edit{}, edits...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var out strings.Builder
+	last := 0
+	for _, e := range sorted {
+		out.WriteString(src[last:e.Start])
+		out.WriteString(e.Text)
+		last = e.End
+	}
+	out.WriteString(src[last:])
+	return out.String()
+}
+
+// Rename offsetにある識別子が指す束縛の名前を、束縛とその参照全てでnewNameに変える
+func Rename(src string, offset int, newName string) ([]Edit, error) {
+	if !isIdentifier(newName) {
+		return nil, fmt.Errorf("%q is not a valid identifier", newName)
+	}
+
+	tree := cst.Parse(src)
+	if len(tree.Errors) > 0 {
+		return nil, fmt.Errorf("cannot rename in a file with syntax errors: %s", tree.Errors[0])
+	}
+
+	target := identifierAt(tree, offset)
+	if target == nil {
+		return nil, fmt.Errorf("no identifier at offset %d", offset)
+	}
+
+	bindings, refs := checker.Resolve(tree.Program)
+	var binding *checker.Binding
+	for _, r := range refs {
+		if r.Ident == tree.ASTNode(target) {
+			binding = r.Binding
+		}
+	}
+	if binding == nil {
+		return nil, fmt.Errorf("%s is not bound in this file", target.Text())
+	}
+	for _, b := range bindings {
+		if b.Name.Value == newName {
+			return nil, fmt.Errorf("%s is already bound at %s", newName, b.Name.Token.Pos)
+		}
+	}
+
+	var edits []Edit
+	for _, r := range refs {
+		if r.Binding != binding {
+			continue
+		}
+		n := tree.NodeOf(r.Ident)
+		if n == nil {
+			return nil, fmt.Errorf("no syntax node for %s at %s", r.Ident.Value, r.Ident.Token.Pos)
+		}
+		for _, tok := range n.Tokens() {
+			if tok.Type() == mtoken.IDENT {
+				edits = append(edits, Edit{Start: tok.Start(), End: tok.End(), Text: newName})
+			}
+		}
+	}
+	return edits, nil
+}
+
+// identifierAt offsetの位置か直前にある識別子のノード
+func identifierAt(tree *cst.Tree, offset int) *cst.Node {
+	for _, o := range []int{offset, offset - 1} {
+		tok := tree.Root.TokenAt(o)
+		if tok != nil && tok.Type() == mtoken.IDENT && tok.Parent.Kind() == cst.Identifier {
+			return tok.Parent
+		}
+	}
+	return nil
+}
+
+func isIdentifier(name string) bool {
+	if name == "" || mtoken.LookupIdent(name) != mtoken.IDENT {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		ch := name[i]
+		if !('a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_') {
+			return false
+		}
+	}
+	return true
+}
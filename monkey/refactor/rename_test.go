@@ -0,0 +1,64 @@
+package refactor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRename(t *testing.T) {
+	src := `import "lib.mk" as lib;
+let total  =  1 ; // keep   this
+let other = total * lib.total;
+-total;
+`
+	want := `import "lib.mk" as lib;
+let sum  =  1 ; // keep   this
+let other = sum * lib.total;
+-sum;
+`
+
+	// 束縛の名前、参照、参照の直後のどこから始めても同じ結果になる
+	for _, offset := range []int{strings.Index(src, "total"), strings.Index(src, "-total") + 1, strings.Index(src, "-total") + 6} {
+		edits, err := Rename(src, offset, "sum")
+		if err != nil {
+			t.Fatalf("Rename(%d) returned error: %v", offset, err)
+		}
+		if len(edits) != 3 {
+			t.Errorf("Rename(%d) returned %d edits, want 3", offset, len(edits))
+		}
+		if got := Apply(src, edits); got != want {
+			t.Errorf("Rename(%d) =\n%s\nwant\n%s", offset, got, want)
+		}
+	}
+
+	edits, err := Rename(src, strings.Index(src, "lib;"), "m")
+	if err != nil {
+		t.Fatalf("Rename returned error: %v", err)
+	}
+	if got := Apply(src, edits); !strings.Contains(got, `as m;`) || !strings.Contains(got, "m.total") {
+		t.Errorf("Rename of an import alias =\n%s", got)
+	}
+}
+
+func TestRenameErrors(t *testing.T) {
+	tests := []struct {
+		src      string
+		offset   int
+		newName  string
+		expected string
+	}{
+		{"let a = 1;", 4, "let", `"let" is not a valid identifier`},
+		{"let a = 1;", 4, "a1", `"a1" is not a valid identifier`},
+		{"let a = 1;", 8, "b", "no identifier at offset 8"},
+		{"let a = 1; let b = a;", 4, "b", "b is already bound at 1:16"},
+		{"x;", 0, "y", "x is not bound in this file"},
+		{"let = 1;", 0, "y", "cannot rename in a file with syntax errors: expected next token to be IDENT, got = instead"},
+	}
+
+	for _, tt := range tests {
+		_, err := Rename(tt.src, tt.offset, tt.newName)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("Rename(%q, %d, %q) error = %v, want %q", tt.src, tt.offset, tt.newName, err, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package repl
+
+// 「:」で始まるメタコマンド。
+// * :reset 溜めている途中の入力を捨てる
+// * :load file.mk ファイルを評価してセッションに取り込む
+// * :tokens expr NextTokenが返すトークンを1つずつ出す
+// * :ast expr 構文木をインデントした木で出す
+// * :type expr 式の型を推論して出す
+// * :env 束縛した名前と値を出す
+// * :time expr 字句解析から構文解析までにかかった時間とアロケーションの回数を出す
+// * :set [name on|off] 入力のたびに:tokens、:ast、:timeと同じものを出すかどうかを切り替える
+// 評価器はまだ無いので、値は束縛した式そのもので、時間は構文解析までを測る。
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/dump"
+	"github.com/tMinamiii/various-parser/monkey/lexer"
+	"github.com/tMinamiii/various-parser/monkey/mtoken"
+)
+
+// metaCommands 補完に使うメタコマンドの名前
+var metaCommands = []string{":reset", ":load", ":tokens", ":ast", ":type", ":env", ":time", ":set"}
+
+// options :setで切り替えるもの
+type options struct {
+	tokens bool
+	ast    bool
+	time   bool
+}
+
+func (o *options) lookup(name string) *bool {
+	switch name {
+	case "tokens":
+		return &o.tokens
+	case "ast":
+		return &o.ast
+	case "time":
+		return &o.time
+	}
+	return nil
+}
+
+func (s *Session) command(line string) {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch name {
+	case ":reset":
+		s.Reset()
+	case ":load":
+		// 空白を含むパスもあるので、行の残りをそのままパスにする
+		if arg == "" {
+			fmt.Fprintln(s.out, "usage: :load file.mk")
+			return
+		}
+		if s.Pending() {
+			fmt.Fprintln(s.out, "finish the current input or :reset before :load")
+			return
+		}
+		s.load(arg)
+	case ":tokens":
+		s.printTokens(arg)
+	case ":ast":
+		s.printAST(arg)
+	case ":type":
+		s.printType(arg)
+	case ":env":
+		s.printEnv()
+	case ":time":
+		var ok bool
+		elapsed, allocs := measure(func() { _, ok = s.parse(arg) })
+		if ok {
+			printTime(s.out, elapsed, allocs)
+		}
+	case ":set":
+		s.set(strings.Fields(arg))
+	default:
+		fmt.Fprintf(s.out, "unknown command %s\n", name)
+	}
+}
+
+// load ファイルの束縛を取り込む。文は出力せず、取り込んだ数だけを出す
+func (s *Session) load(name string) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	program, ok := s.parse(string(b))
+	if !ok {
+		return
+	}
+	s.bind(program)
+	fmt.Fprintf(s.out, "loaded %d statements from %s\n", len(program.Statements), name)
+}
+
+func (s *Session) printTokens(src string) {
+	l := lexer.NewLexer(src)
+	for tok := l.NextToken(); tok.Type != mtoken.EOF; tok = l.NextToken() {
+		fmt.Fprintf(s.out, "%+v\n", tok)
+	}
+}
+
+// printAST 式文が1つだけなら式の木を、そうでなければプログラム全体の木を出す
+func (s *Session) printAST(src string) {
+	program, ok := s.parse(src)
+	if !ok {
+		return
+	}
+	var node ast.Node = program
+	if len(program.Statements) == 1 {
+		if es, ok := program.Statements[0].(*ast.ExpressionStatement); ok {
+			node = es.Expression
+		}
+	}
+	dump.Tree(s.out, node)
+}
+
+func (s *Session) printType(src string) {
+	program, ok := s.parse(src)
+	if !ok {
+		return
+	}
+	if len(program.Statements) != 1 {
+		fmt.Fprintln(s.out, "usage: :type expr")
+		return
+	}
+	es, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		fmt.Fprintln(s.out, "usage: :type expr")
+		return
+	}
+	t, err := s.typeOf(es.Expression, map[string]bool{})
+	if err != nil {
+		fmt.Fprintln(s.out, err)
+		return
+	}
+	fmt.Fprintln(s.out, t)
+}
+
+// printEnv 束縛した順に「名前 = 式」を出す
+func (s *Session) printEnv() {
+	for _, name := range s.names {
+		fmt.Fprintf(s.out, "%s = %s\n", name, s.env[name].Value.String())
+	}
+}
+
+// set 引数が無ければ全ての設定を出す
+func (s *Session) set(args []string) {
+	if len(args) == 0 {
+		for _, name := range []string{"tokens", "ast", "time"} {
+			value := "off"
+			if *s.options.lookup(name) {
+				value = "on"
+			}
+			fmt.Fprintf(s.out, "%s %s\n", name, value)
+		}
+		return
+	}
+
+	if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+		fmt.Fprintln(s.out, "usage: :set [tokens|ast|time on|off]")
+		return
+	}
+	option := s.options.lookup(args[0])
+	if option == nil {
+		fmt.Fprintf(s.out, "unknown option %s\n", args[0])
+		return
+	}
+	*option = args[1] == "on"
+}
+
+// measure fにかかった時間とアロケーションの回数
+func measure(f func()) (time.Duration, uint64) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	f()
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+	return elapsed, after.Mallocs - before.Mallocs
+}
+
+func printTime(out io.Writer, elapsed time.Duration, allocs uint64) {
+	fmt.Fprintf(out, "time: %s, allocs: %d\n", elapsed, allocs)
+}
+
+// rcPath 起動時に読むファイルの場所。MONKEYRCが無ければホームディレクトリの.monkeyrc
+func rcPath() string {
+	if path := os.Getenv("MONKEYRC"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".monkeyrc")
+}
+
+// LoadRC pathの各行をREPLに打ち込んだものとして読む。ファイルが無ければ何もしない
+func (s *Session) LoadRC(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		s.Feed(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	s.Close()
+	return nil
+}
+
+// typeOf 式の型。intとbool以外や、型の分からない名前はunknownになる。
+// visitingは束縛を辿るときの循環を防ぐ
+func (s *Session) typeOf(e ast.Expression, visiting map[string]bool) (string, error) {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		return "int", nil
+	case *ast.Identifier:
+		let, ok := s.env[e.Value]
+		if !ok {
+			return "", fmt.Errorf("undefined: %s", e.Value)
+		}
+		if visiting[e.Value] {
+			return "unknown", nil
+		}
+		visiting[e.Value] = true
+		defer delete(visiting, e.Value)
+		return s.typeOf(let.Value, visiting)
+	case *ast.PrefixExpression:
+		right, err := s.typeOf(e.Right, visiting)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case e.Operator == "!":
+			return "bool", nil
+		case right == "int" || right == "unknown":
+			return right, nil
+		}
+		return "", fmt.Errorf("invalid operation: %s%s", e.Operator, right)
+	case *ast.InfixExpression:
+		left, err := s.typeOf(e.Left, visiting)
+		if err != nil {
+			return "", err
+		}
+		right, err := s.typeOf(e.Right, visiting)
+		if err != nil {
+			return "", err
+		}
+		return infixType(e.Operator, left, right)
+	}
+	return "unknown", nil
+}
+
+func infixType(op string, left string, right string) (string, error) {
+	if op == "==" || op == "!=" {
+		if left == right || left == "unknown" || right == "unknown" {
+			return "bool", nil
+		}
+		return "", fmt.Errorf("mismatched types: %s %s %s", left, op, right)
+	}
+
+	if (left != "int" && left != "unknown") || (right != "int" && right != "unknown") {
+		return "", fmt.Errorf("invalid operation: %s %s %s", left, op, right)
+	}
+	switch {
+	case op == "<" || op == ">":
+		return "bool", nil
+	case left == "unknown" || right == "unknown":
+		return "unknown", nil
+	}
+	return "int", nil
+}
@@ -0,0 +1,97 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		lines    []string
+		expected string
+	}{
+		{
+			[]string{":tokens let x = 5;"},
+			"{Type:LET Literal:let Pos:1:1}\n{Type:IDENT Literal:x Pos:1:5}\n{Type:= Literal:= Pos:1:7}\n" +
+				"{Type:INT Literal:5 Pos:1:9}\n{Type:; Literal:; Pos:1:10}\n",
+		},
+		{
+			[]string{":ast 1 + 2 * x"},
+			"InfixExpression + 1:1\n  Left: IntegerLiteral 1 1:1\n  Right: InfixExpression * 1:5\n" +
+				"    Left: IntegerLiteral 2 1:5\n    Right: Identifier x 1:9\n",
+		},
+		{
+			[]string{":ast let a = 1;"},
+			"Program 1:1\n  Statements[0]: LetStatement 1:1\n    Name: Identifier a 1:5\n    Value: IntegerLiteral 1 1:9\n",
+		},
+		{[]string{":ast let = 1;"}, "parser errors:\n\t1:5: expected next token to be IDENT, got = instead\n\t1:5: no prefix parse function for = found\n"},
+		{
+			[]string{"let a = 1;", "let b = a + 2;", "let a = 3;", ":env"},
+			"let a = 1;\nlet b = (a + 2);\nlet a = 3;\na = 3\nb = (a + 2)\n",
+		},
+		{[]string{"let a = 1;", "let b = a < 2;", ":type b", ":type -a", ":type !a == b"}, "let a = 1;\nlet b = (a < 2);\nbool\nint\nbool\n"},
+		{[]string{"let b = 1 > 2;", ":type b + 1"}, "let b = (1 > 2);\ninvalid operation: bool + int\n"},
+		{[]string{"let b = 1 > 2;", ":type b == 1"}, "let b = (1 > 2);\nmismatched types: bool == int\n"},
+		{[]string{"let a = a + 1;", ":type a", ":type m.x * 2"}, "let a = (a + 1);\nunknown\nunknown\n"},
+		{[]string{":type c"}, "undefined: c\n"},
+		{[]string{":type let c = 1;"}, "usage: :type expr\n"},
+		{[]string{":set"}, "tokens off\nast off\ntime off\n"},
+		{[]string{":set ast on", ":set", "x"}, "tokens off\nast on\ntime off\nProgram 1:1\n  Statements[0]: ExpressionStatement 1:1\n    Expression: Identifier x 1:1\nx\n"},
+		{[]string{":set tokens on", "x", ":set tokens off", "y"}, "{Type:IDENT Literal:x Pos:1:1}\nx\ny\n"},
+		{[]string{":set color on"}, "unknown option color\n"},
+		{[]string{":set ast maybe"}, "usage: :set [tokens|ast|time on|off]\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		s := NewSession(&out)
+		for _, line := range tt.lines {
+			s.Feed(line)
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%q: output wrong.\ngot=%q\nwant=%q", tt.lines, out.String(), tt.expected)
+		}
+	}
+}
+
+func TestCommandTime(t *testing.T) {
+	var out bytes.Buffer
+	s := NewSession(&out)
+	s.Feed(":time 1 + 2")
+	s.Feed(":set time on")
+	s.Feed("let a = 1;")
+
+	re := regexp.MustCompile(`^time: [0-9.]+[nµm]?s, allocs: [0-9]+\nlet a = 1;\ntime: [0-9.]+[nµm]?s, allocs: [0-9]+\n$`)
+	if !re.MatchString(out.String()) {
+		t.Errorf("output wrong. got=%q", out.String())
+	}
+}
+
+func TestLoadRC(t *testing.T) {
+	dir := t.TempDir()
+	rc := filepath.Join(dir, "monkeyrc")
+	if err := os.WriteFile(rc, []byte(":set ast on\nlet one =\n1;\n:set ast off\nlet two = one +\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	s := NewSession(&out)
+	if err := s.LoadRC(rc); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Program 1:1\n  Statements[0]: LetStatement 1:1\n    Name: Identifier one 1:5\n    Value: IntegerLiteral 1 2:1\nlet one = 1;\n" +
+		"parser errors:\n\t1:16: no prefix parse function for EOF found\n"
+	if out.String() != expected {
+		t.Errorf("output wrong.\ngot=%q\nwant=%q", out.String(), expected)
+	}
+	if s.Pending() {
+		t.Errorf("session is still pending")
+	}
+
+	if err := s.LoadRC(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("missing rc file error wrong. got=%v", err)
+	}
+}
@@ -0,0 +1,453 @@
+package repl
+
+// 端末で使う行エディタ。Emacs風のキー操作だけを持つ。
+// * 移動 ←→、Ctrl-A/E/B/F、Home/End、Alt-B/F(単語単位)
+// * 削除 Backspace、Delete、Ctrl-D/K/U/W
+// * 履歴 ↑↓、Ctrl-P/N、Ctrl-R(逆方向のインクリメンタルサーチ)
+// * 補完 Tab
+// 1文字を1桁として表示位置を計算するので、全角文字があるとカーソルがずれる。
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// errInterrupted Ctrl-Cで入力を取り消した
+var errInterrupted = errors.New("interrupted")
+
+// maxHistory 履歴ファイルに残す行数
+const maxHistory = 1000
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyLF        = 10
+	keyCtrlK     = 11
+	keyCR        = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyBackspace = 127
+)
+
+type editor struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	// raw 端末を1文字ずつ読む設定にし、元に戻す関数を返す。nilなら設定を変えない
+	raw func() (func(), error)
+	// complete カーソルの前の単語を補完する候補
+	complete func(prefix string) []string
+	// highlight 表示する行に色を付ける。nilなら色を付けない
+	highlight func(line string) string
+
+	history []string
+}
+
+func newEditor(in io.Reader, out io.Writer) *editor {
+	return &editor{in: bufio.NewReader(in), out: out}
+}
+
+// line 編集中の行
+type line struct {
+	prompt string
+	buf    []rune
+	pos    int // カーソルの位置。bufの添字
+
+	history int    // 表示している履歴の添字。len(history)なら編集中の行
+	saved   []rune // 履歴を辿る前に編集していた行
+}
+
+func (l *line) insert(rs ...rune) {
+	buf := append([]rune{}, l.buf[:l.pos]...)
+	buf = append(buf, rs...)
+	l.buf = append(buf, l.buf[l.pos:]...)
+	l.pos += len(rs)
+}
+
+// delete [from, to)を消してカーソルをfromに置く
+func (l *line) delete(from, to int) {
+	l.buf = append(l.buf[:from], l.buf[to:]...)
+	l.pos = from
+}
+
+func (l *line) set(rs []rune) {
+	l.buf = append([]rune{}, rs...)
+	l.pos = len(l.buf)
+}
+
+// wordStart カーソルの前にある単語の先頭
+func (l *line) wordStart() int {
+	i := l.pos
+	for i > 0 && unicode.IsSpace(l.buf[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(l.buf[i-1]) {
+		i--
+	}
+	return i
+}
+
+// wordEnd カーソルの後ろにある単語の終わり
+func (l *line) wordEnd() int {
+	i := l.pos
+	for i < len(l.buf) && unicode.IsSpace(l.buf[i]) {
+		i++
+	}
+	for i < len(l.buf) && !unicode.IsSpace(l.buf[i]) {
+		i++
+	}
+	return i
+}
+
+// readLine 1行を編集して返す。Ctrl-CならerrInterrupted、空の行でCtrl-Dならio.EOF
+func (e *editor) readLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	l := &line{prompt: prompt, history: len(e.history)}
+	e.refresh(l)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(l.buf) > 0 {
+				return e.accept(l), nil
+			}
+			return "", err
+		}
+
+		switch r {
+		case keyCR, keyLF:
+			return e.accept(l), nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case keyCtrlD:
+			if len(l.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			if l.pos < len(l.buf) {
+				l.delete(l.pos, l.pos+1)
+			}
+		case keyCtrlA:
+			l.pos = 0
+		case keyCtrlE:
+			l.pos = len(l.buf)
+		case keyCtrlB:
+			if l.pos > 0 {
+				l.pos--
+			}
+		case keyCtrlF:
+			if l.pos < len(l.buf) {
+				l.pos++
+			}
+		case keyBackspace, keyCtrlH:
+			if l.pos > 0 {
+				l.delete(l.pos-1, l.pos)
+			}
+		case keyCtrlK:
+			l.buf = l.buf[:l.pos]
+		case keyCtrlU:
+			l.delete(0, l.pos)
+		case keyCtrlW:
+			l.delete(l.wordStart(), l.pos)
+		case keyCtrlP:
+			e.historyMove(l, -1)
+		case keyCtrlN:
+			e.historyMove(l, 1)
+		case keyCtrlR:
+			if err := e.search(l); err != nil {
+				return "", err
+			}
+		case keyTab:
+			e.completeWord(l)
+		case keyEscape:
+			if err := e.escape(l); err != nil {
+				return "", err
+			}
+		default:
+			if unicode.IsPrint(r) {
+				l.insert(r)
+			}
+		}
+		e.refresh(l)
+	}
+}
+
+// accept 行を確定して履歴に加える
+func (e *editor) accept(l *line) string {
+	fmt.Fprint(e.out, "\r\n")
+	s := string(l.buf)
+	if strings.TrimSpace(s) != "" && (len(e.history) == 0 || e.history[len(e.history)-1] != s) {
+		e.history = append(e.history, s)
+	}
+	return s
+}
+
+// refresh 行を書き直してカーソルを置く
+func (e *editor) refresh(l *line) {
+	text := string(l.buf)
+	if e.highlight != nil {
+		// 色のエスケープシーケンスは桁を取らないので、カーソルの移動量は変わらない
+		text = e.highlight(text)
+	}
+	s := "\r" + l.prompt + text + "\x1b[K"
+	if n := len(l.buf) - l.pos; n > 0 {
+		s += fmt.Sprintf("\x1b[%dD", n)
+	}
+	io.WriteString(e.out, s)
+}
+
+// escape ESCに続くシーケンス。矢印キーなどは「ESC [ 引数 終端文字」で届く
+func (e *editor) escape(l *line) error {
+	r, _, err := e.in.ReadRune()
+	if err != nil {
+		return err
+	}
+	switch r {
+	case 'b':
+		l.pos = l.wordStart()
+		return nil
+	case 'f':
+		l.pos = l.wordEnd()
+		return nil
+	case '[', 'O':
+	default:
+		return nil
+	}
+
+	var seq []rune
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return err
+		}
+		seq = append(seq, r)
+		if 0x40 <= r && r <= 0x7e {
+			break
+		}
+	}
+
+	switch string(seq) {
+	case "A":
+		e.historyMove(l, -1)
+	case "B":
+		e.historyMove(l, 1)
+	case "C":
+		if l.pos < len(l.buf) {
+			l.pos++
+		}
+	case "D":
+		if l.pos > 0 {
+			l.pos--
+		}
+	case "H", "1~", "7~":
+		l.pos = 0
+	case "F", "4~", "8~":
+		l.pos = len(l.buf)
+	case "3~":
+		if l.pos < len(l.buf) {
+			l.delete(l.pos, l.pos+1)
+		}
+	}
+	return nil
+}
+
+// historyMove 履歴をdirだけ進める。-1で古い方へ
+func (e *editor) historyMove(l *line, dir int) {
+	next := l.history + dir
+	if next < 0 || next > len(e.history) {
+		return
+	}
+	if l.history == len(e.history) {
+		l.saved = append([]rune{}, l.buf...)
+	}
+	l.history = next
+	if next == len(e.history) {
+		l.set(l.saved)
+	} else {
+		l.set([]rune(e.history[next]))
+	}
+}
+
+// search Ctrl-Rの逆方向インクリメンタルサーチ。
+// 文字を打つと絞り込み、Ctrl-Rでさらに古い一致に進む。Ctrl-Gで取り消す。
+// それ以外のキーは見つけた行を確定し、そのキーを通常の編集に回す
+func (e *editor) search(l *line) error {
+	var query []rune
+	match := -1
+	find := func(from int) int {
+		if len(query) == 0 {
+			return -1
+		}
+		for i := from; i >= 0; i-- {
+			if strings.Contains(e.history[i], string(query)) {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for {
+		text := ""
+		if match >= 0 {
+			text = e.history[match]
+		}
+		fmt.Fprintf(e.out, "\r(reverse-i-search)`%s': %s\x1b[K", string(query), text)
+
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return err
+		}
+		switch {
+		case r == keyCtrlR:
+			// 今の一致と同じ行は飛ばす
+			for m := find(match - 1); m >= 0; m = find(m - 1) {
+				if e.history[m] != e.history[match] {
+					match = m
+					break
+				}
+			}
+		case r == keyBackspace || r == keyCtrlH:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			match = find(len(e.history) - 1)
+		case r == keyCtrlG || r == keyCtrlC:
+			return nil
+		case unicode.IsPrint(r):
+			query = append(query, r)
+			from := match
+			if from < 0 {
+				from = len(e.history) - 1
+			}
+			match = find(from)
+		default:
+			if match >= 0 {
+				l.set([]rune(e.history[match]))
+				l.history = len(e.history)
+			}
+			return e.in.UnreadRune()
+		}
+	}
+}
+
+// completeWord カーソルの前の単語を補完する。
+// 候補が1つならそれに、複数なら共通の接頭辞まで伸ばす。伸ばせなければ候補を一覧する
+func (e *editor) completeWord(l *line) {
+	if e.complete == nil {
+		return
+	}
+	start := l.pos
+	for start > 0 && isWordRune(l.buf[start-1]) {
+		start--
+	}
+	prefix := string(l.buf[start:l.pos])
+	if prefix == "" {
+		return
+	}
+
+	candidates := e.complete(prefix)
+	if len(candidates) == 0 {
+		io.WriteString(e.out, "\a")
+		return
+	}
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, common) {
+			common = common[:len(common)-1]
+		}
+	}
+	if len(common) > len(prefix) {
+		l.insert([]rune(common[len(prefix):])...)
+		return
+	}
+	if len(candidates) > 1 {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// isWordRune 補完する単語に含まれる文字。メタコマンドのために「:」も含める
+func isWordRune(r rune) bool {
+	return r == '_' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// historyPath 履歴ファイルの場所。MONKEY_HISTORYが無ければホームディレクトリの.monkey_history
+func historyPath() string {
+	if path := os.Getenv("MONKEY_HISTORY"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".monkey_history")
+}
+
+// loadHistory 1行に1件の履歴を読む。ファイルが無ければ空の履歴
+func loadHistory(path string) ([]string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var history []string
+	for _, s := range strings.Split(string(b), "\n") {
+		if s != "" {
+			history = append(history, s)
+		}
+	}
+	return history, nil
+}
+
+// saveHistory 新しい方からmaxHistory件を書く
+func saveHistory(path string, history []string) error {
+	if len(history) > maxHistory {
+		history = history[len(history)-maxHistory:]
+	}
+	var b strings.Builder
+	for _, s := range history {
+		b.WriteString(s + "\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// completions prefixで始まる候補を重複なく辞書順に
+func completions(prefix string, words ...[]string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, ws := range words {
+		for _, w := range ws {
+			if strings.HasPrefix(w, prefix) && !seen[w] {
+				seen[w] = true
+				result = append(result, w)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,173 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditorReadLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"plain", "let x = 1;\r", "let x = 1;"},
+		{"backspace", "lett\x7f x\r", "let x"},
+		{"left and insert", "lt\x1b[Dex\x1b[C\r", "lext"},
+		{"home and end", "et\x01l\x05!\r", "let!"},
+		{"home and end keys", "bc\x1b[Ha\x1b[Fd\r", "abcd"},
+		{"delete key", "abc\x01\x1b[3~\r", "bc"},
+		{"ctrl-d deletes", "abc\x01\x04\r", "bc"},
+		{"kill to end", "abc\x02\x02\x0b\r", "a"},
+		{"kill to start", "abc\x02\x15\r", "c"},
+		{"delete word", "let x = foo\x17bar\r", "let x = bar"},
+		{"word moves", "one two\x1bbX\x1bfY\r", "one XtwoY"},
+		{"utf-8", "あい\x7fう\r", "あう"},
+		{"eof after text", "x", "x"},
+	}
+
+	for _, tt := range tests {
+		e := newEditor(strings.NewReader(tt.keys), io.Discard)
+		got, err := e.readLine(PROMPT)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.expected {
+			t.Errorf("%s: line wrong. got=%q, want=%q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestEditorHighlight(t *testing.T) {
+	var out bytes.Buffer
+	e := newSessionEditor(strings.NewReader("let x\r:ast on\r"), &out, NewSession(io.Discard))
+
+	if _, err := e.readLine(PROMPT); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "\r>> \x1b[35mlet\x1b[0m x\x1b[K"; !strings.Contains(out.String(), expected) {
+		t.Errorf("keyword not highlighted. got=%q, want it to contain %q", out.String(), expected)
+	}
+
+	out.Reset()
+	if _, err := e.readLine(PROMPT); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "\r>> :ast on\x1b[K"; !strings.Contains(out.String(), expected) {
+		t.Errorf("meta command should not be highlighted. got=%q", out.String())
+	}
+}
+
+func TestEditorControl(t *testing.T) {
+	e := newEditor(strings.NewReader("abc\x03\x04"), io.Discard)
+	if _, err := e.readLine(PROMPT); err != errInterrupted {
+		t.Errorf("ctrl-c error wrong. got=%v", err)
+	}
+	if _, err := e.readLine(PROMPT); err != io.EOF {
+		t.Errorf("ctrl-d error wrong. got=%v", err)
+	}
+}
+
+func TestEditorHistory(t *testing.T) {
+	keys := "let a = 1;\r" +
+		"let b = 2;\r" +
+		"let b = 2;\r" +
+		" \r" +
+		"x\x1b[A\x1b[A\r" + // 2つ前
+		"x\x10\x10\x0e\x0e\r" + // 戻って編集中の行
+		"\x12a = \r" + // 逆方向サーチ
+		"\x12let\x12\x12\x05!\r" // 一致を辿ってから編集
+	expected := []string{"let a = 1;", "let b = 2;", "let b = 2;", " ", "let a = 1;", "x", "let a = 1;", "let a = 1;!"}
+
+	e := newEditor(strings.NewReader(keys), io.Discard)
+	for i, want := range expected {
+		got, err := e.readLine(PROMPT)
+		if err != nil {
+			t.Fatalf("line %d: %v", i, err)
+		}
+		if got != want {
+			t.Errorf("line %d wrong. got=%q, want=%q", i, got, want)
+		}
+	}
+
+	history := []string{"let a = 1;", "let b = 2;", "let a = 1;", "x", "let a = 1;", "let a = 1;!"}
+	if !reflect.DeepEqual(e.history, history) {
+		t.Errorf("history wrong. got=%q, want=%q", e.history, history)
+	}
+}
+
+func TestEditorSearchCancel(t *testing.T) {
+	e := newEditor(strings.NewReader("\x12let\x07x\r"), io.Discard)
+	e.history = []string{"let a = 1;"}
+	got, err := e.readLine(PROMPT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "x" {
+		t.Errorf("line wrong. got=%q", got)
+	}
+}
+
+func TestEditorComplete(t *testing.T) {
+	s := NewSession(io.Discard)
+	s.Feed("let counter = 1;")
+	s.Feed("let count = 2;")
+
+	tests := []struct {
+		keys     string
+		expected string
+		output   string
+	}{
+		{"le\t x\r", "let x", ""},
+		{"ret\t\r", "return", ""},
+		{"x + cou\t\r", "x + count", ""},
+		{"x + count\t\r", "x + count", "count  counter"},
+		{"zz\t\r", "zz", "\a"},
+		{":re\t\r", ":reset", ""},
+		{"\t\r", "", ""},
+	}
+
+	for _, tt := range tests {
+		var out strings.Builder
+		e := newEditor(strings.NewReader(tt.keys), &out)
+		e.complete = s.Complete
+		got, err := e.readLine(PROMPT)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.expected {
+			t.Errorf("%q: line wrong. got=%q, want=%q", tt.keys, got, tt.expected)
+		}
+		if !strings.Contains(out.String(), tt.output) {
+			t.Errorf("%q: output wrong. got=%q, want %q in it", tt.keys, out.String(), tt.output)
+		}
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+
+	history, err := loadHistory(path)
+	if err != nil || history != nil {
+		t.Fatalf("missing file wrong. got=%q, %v", history, err)
+	}
+
+	var lines []string
+	for i := 0; i < maxHistory+10; i++ {
+		lines = append(lines, strings.Repeat("x", i%7+1))
+	}
+	if err := saveHistory(path, lines); err != nil {
+		t.Fatal(err)
+	}
+	history, err = loadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(history, lines[10:]) {
+		t.Errorf("history wrong. got %d lines, want %d", len(history), maxHistory)
+	}
+}
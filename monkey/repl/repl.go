@@ -4,27 +4,257 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 
+	"github.com/tMinamiii/various-parser/monkey/ast"
+	"github.com/tMinamiii/various-parser/monkey/dump"
+	"github.com/tMinamiii/various-parser/monkey/highlight"
 	"github.com/tMinamiii/various-parser/monkey/lexer"
 	"github.com/tMinamiii/various-parser/monkey/mtoken"
+	"github.com/tMinamiii/various-parser/monkey/parser"
 )
 
 const PROMPT = ">> "
 
+// CONTINUE_PROMPT 入力が途中で終わっている間のプロンプト
+const CONTINUE_PROMPT = ".. "
+
+// StartREPL inとoutが端末なら行エディタで、そうでなければ1行ずつ読む。
+// rcファイルは端末のときだけ読む。パイプで流した入力の結果が設定で変わらないように
 func StartREPL(in io.Reader, out io.Writer) {
+	s := NewSession(out)
+
+	if f, ok := in.(*os.File); ok && isTerminal(f.Fd()) {
+		if o, ok := out.(*os.File); ok && isTerminal(o.Fd()) {
+			if path := rcPath(); path != "" {
+				if err := s.LoadRC(path); err != nil {
+					fmt.Fprintf(out, "cannot load %s: %s\n", path, err)
+				}
+			}
+			startEditor(f, out, s)
+			return
+		}
+	}
+
 	scanner := bufio.NewScanner(in)
 	for {
-		fmt.Print(PROMPT)
+		fmt.Fprint(out, s.Prompt())
 		scanned := scanner.Scan()
 		if !scanned {
+			s.Close()
 			return
 		}
+		s.Feed(scanner.Text())
+	}
+}
+
+// startEditor 履歴ファイルを読み書きしながら行エディタで読む
+func startEditor(in *os.File, out io.Writer, s *Session) {
+	e := newSessionEditor(in, out, s)
+	e.raw = func() (func(), error) { return makeRaw(in.Fd()) }
+
+	path := historyPath()
+	if path != "" {
+		history, err := loadHistory(path)
+		if err != nil {
+			fmt.Fprintf(out, "cannot load history: %s\n", err)
+		}
+		e.history = history
+	}
+
+	for {
+		line, err := e.readLine(s.Prompt())
+		if err == errInterrupted {
+			s.Reset()
+			continue
+		}
+		if err != nil {
+			break
+		}
+		s.Feed(line)
+	}
+	s.Close()
+
+	if path != "" {
+		if err := saveHistory(path, e.history); err != nil {
+			fmt.Fprintf(out, "cannot save history: %s\n", err)
+		}
+	}
+}
+
+// newSessionEditor sの名前で補完し、入力中の行に色を付ける行エディタ
+func newSessionEditor(in io.Reader, out io.Writer, s *Session) *editor {
+	e := newEditor(in, out)
+	e.complete = s.Complete
+	e.highlight = highlightInput
+	return e
+}
+
+// highlightInput 入力中の行に色を付ける。メタコマンドはMonkeyのコードではないのでそのまま
+func highlightInput(line string) string {
+	if strings.HasPrefix(strings.TrimSpace(line), ":") {
+		return line
+	}
+	return highlight.ANSI(line)
+}
+
+// Session REPLの1回の起動の間の状態。
+// * 入力が途中で終わっていれば、続きの行を待つ
+// * let文で束縛した名前を覚えておく
+type Session struct {
+	out     io.Writer
+	pending []string // まだ評価していない行
+
+	env   map[string]*ast.LetStatement
+	names []string // envの名前を束縛した順に
+
+	options options // :setで切り替える
+}
+
+func NewSession(out io.Writer) *Session {
+	return &Session{out: out, env: make(map[string]*ast.LetStatement)}
+}
+
+// Pending 続きの行を待っているかどうか
+func (s *Session) Pending() bool {
+	return len(s.pending) > 0
+}
+
+// Prompt 次の行のためのプロンプト
+func (s *Session) Prompt() string {
+	if s.Pending() {
+		return CONTINUE_PROMPT
+	}
+	return PROMPT
+}
+
+// Reset 溜めている途中の入力を捨てる
+func (s *Session) Reset() {
+	s.pending = nil
+}
+
+// Complete prefixの補完候補。「:」で始まればメタコマンド、それ以外はキーワードと束縛した名前
+func (s *Session) Complete(prefix string) []string {
+	if strings.HasPrefix(prefix, ":") {
+		return completions(prefix, metaCommands)
+	}
+	return completions(prefix, mtoken.Keywords(), s.names)
+}
+
+// Names セッションで束縛された名前。束縛した順
+func (s *Session) Names() []string {
+	return append([]string{}, s.names...)
+}
+
+// Feed 1行を受け取る。「:」で始まる行はメタコマンド。
+// 入力が途中で終わっていれば溜めておき、続きの行と合わせて評価する。
+// 続きを待っている間に空行が来たら、途中でもそこまでを評価する。
+func (s *Session) Feed(line string) {
+	if strings.HasPrefix(strings.TrimSpace(line), ":") {
+		s.command(strings.TrimSpace(line))
+		return
+	}
+
+	if s.Pending() && strings.TrimSpace(line) == "" {
+		s.flush()
+		return
+	}
+
+	s.pending = append(s.pending, line)
+	if incomplete(strings.Join(s.pending, "\n")) {
+		return
+	}
+	s.flush()
+}
+
+// Close 入力の終わり。続きを待っている行があれば、途中でもそこまでを評価する
+func (s *Session) Close() {
+	if s.Pending() {
+		s.flush()
+	}
+}
+
+func (s *Session) flush() {
+	src := strings.Join(s.pending, "\n")
+	s.pending = nil
+	s.Eval(src)
+}
+
+// Eval srcを構文解析し、文を1行ずつ出力する。構文エラーがあれば何も束縛しない。
+// :setで有効にした出力があれば合わせて出す
+func (s *Session) Eval(src string) {
+	if s.options.tokens {
+		s.printTokens(src)
+	}
+
+	var program *ast.Program
+	var ok bool
+	elapsed, allocs := measure(func() { program, ok = s.parse(src) })
+	if !ok {
+		return
+	}
+	if s.options.ast {
+		dump.Tree(s.out, program)
+	}
+	s.bind(program)
+	for _, stmt := range program.Statements {
+		fmt.Fprintln(s.out, stmt.String())
+	}
+	if s.options.time {
+		printTime(s.out, elapsed, allocs)
+	}
+}
+
+func (s *Session) parse(src string) (*ast.Program, bool) {
+	p := parser.NewParser(lexer.NewLexer(src))
+	program := p.ParseProgram()
+	if errs := p.ParseErrors(); len(errs) > 0 {
+		fmt.Fprintln(s.out, "parser errors:")
+		for _, e := range errs {
+			fmt.Fprintf(s.out, "\t%s\n", e)
+		}
+		return nil, false
+	}
+	return program, true
+}
 
-		line := scanner.Text()
-		l := lexer.NewLexer(line)
+func (s *Session) bind(program *ast.Program) {
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok {
+			continue
+		}
+		if _, ok := s.env[let.Name.Value]; !ok {
+			s.names = append(s.names, let.Name.Value)
+		}
+		s.env[let.Name.Value] = let
+	}
+}
+
+// incomplete srcが途中で終わっているかどうか。
+// 括弧が閉じていないか、構文エラーが入力の終わりで起きていれば続きがあるとみなす。
+func incomplete(src string) bool {
+	depth := 0
+	l := lexer.NewLexer(src)
+	for tok := l.NextToken(); tok.Type != mtoken.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case mtoken.L_PAREN, mtoken.L_BRACE:
+			depth++
+		case mtoken.R_PAREN, mtoken.R_BRACE:
+			depth--
+		}
+	}
+	if depth > 0 {
+		return true
+	}
 
-		for tok := l.NextToken(); tok.Type != mtoken.EOF; tok = l.NextToken() {
-			fmt.Printf("%+v\n", tok)
+	p := parser.NewParser(lexer.NewLexer(src))
+	p.ParseProgram()
+	for _, e := range p.ParseErrors() {
+		if e.Pos.Offset >= len(src) {
+			return true
 		}
 	}
+	return false
 }
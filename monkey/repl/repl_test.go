@@ -0,0 +1,115 @@
+package repl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStartREPL(t *testing.T) {
+	// パイプで流した入力ではrcファイルを読まない
+	rc := filepath.Join(t.TempDir(), "monkeyrc")
+	if err := os.WriteFile(rc, []byte(":set tokens on\nlet r = 1;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MONKEYRC", rc)
+
+	input := `let x = 1 +
+2 *
+3;
+let y = x +
+:reset
+let z = 5;
+`
+	expected := ">> " +
+		".. " +
+		".. let x = (1 + (2 * 3));\n" +
+		">> " +
+		".. " +
+		">> let z = 5;\n" +
+		">> "
+
+	var out bytes.Buffer
+	StartREPL(strings.NewReader(input), &out)
+	if out.String() != expected {
+		t.Errorf("output wrong.\ngot=%q\nwant=%q", out.String(), expected)
+	}
+}
+
+func TestStartREPLPendingAtEOF(t *testing.T) {
+	t.Setenv("MONKEYRC", filepath.Join(t.TempDir(), "monkeyrc"))
+
+	// 入力が途中で終わっても捨てずに評価し、エラーを出す
+	var out bytes.Buffer
+	StartREPL(strings.NewReader("let x = 1 +\n"), &out)
+	expected := ">> .. parser errors:\n\t1:12: no prefix parse function for EOF found\n"
+	if out.String() != expected {
+		t.Errorf("output wrong.\ngot=%q\nwant=%q", out.String(), expected)
+	}
+}
+
+func TestSessionFeed(t *testing.T) {
+	tests := []struct {
+		lines    []string
+		pending  bool
+		expected string
+	}{
+		{[]string{"let a = 1;"}, false, "let a = 1;\n"},
+		{[]string{"let a ="}, true, ""},
+		{[]string{"let a =", "-b;"}, false, "let a = (-b);\n"},
+		{[]string{"{"}, true, ""},
+		{[]string{"let a = 1 +", ""}, false, "parser errors:\n\t1:12: no prefix parse function for EOF found\n"},
+		{[]string{"let = 1;"}, false, "parser errors:\n\t1:5: expected next token to be IDENT, got = instead\n\t1:5: no prefix parse function for = found\n"},
+		{[]string{"let a =", ":reset"}, false, ""},
+		{[]string{":nope"}, false, "unknown command :nope\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		s := NewSession(&out)
+		for _, line := range tt.lines {
+			s.Feed(line)
+		}
+		if s.Pending() != tt.pending {
+			t.Errorf("%q: pending wrong. got=%t", tt.lines, s.Pending())
+		}
+		if out.String() != tt.expected {
+			t.Errorf("%q: output wrong. got=%q, want=%q", tt.lines, out.String(), tt.expected)
+		}
+	}
+}
+
+func TestSessionLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "my lib")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "lib.mk")
+	if err := os.WriteFile(file, []byte("let a = 1;\nlet b = a + 1;\na;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	s := NewSession(&out)
+	s.Feed("let c = 3;")
+	s.Feed(":load " + file)
+	s.Feed("let a = 2;")
+
+	expected := "let c = 3;\nloaded 3 statements from " + file + "\nlet a = 2;\n"
+	if out.String() != expected {
+		t.Errorf("output wrong. got=%q, want=%q", out.String(), expected)
+	}
+	if names := s.Names(); !reflect.DeepEqual(names, []string{"c", "a", "b"}) {
+		t.Errorf("names wrong. got=%q", names)
+	}
+
+	out.Reset()
+	s.Feed("let d =")
+	s.Feed(":load " + file)
+	if out.String() != "finish the current input or :reset before :load\n" {
+		t.Errorf("output wrong. got=%q", out.String())
+	}
+}
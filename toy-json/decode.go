@@ -0,0 +1,524 @@
+// Package toyjson converts between JSON documents and Go values.
+package toyjson
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/tMinamiii/various-parser/toy-json/lexer"
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// Unmarshaler is implemented by types that decode their own JSON.
+// UnmarshalJSON receives the raw bytes of one JSON value.
+type Unmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
+// UnmarshalTypeError reports a JSON value that does not fit the Go type at Path.
+type UnmarshalTypeError struct {
+	Value  string       // the JSON kind, e.g. "string" or "number 1.5"
+	Type   reflect.Type // the Go type it could not be stored in
+	Path   string       // e.g. $.items[2].name
+	Offset int          // byte offset of the value in the input
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	return fmt.Sprintf("toyjson: cannot unmarshal %s into Go value of type %s at %s (offset %d)",
+		e.Value, e.Type, e.Path, e.Offset)
+}
+
+// SyntaxError is malformed JSON, with the line and column where it was found.
+// Its Snippet method shows the offending line with a caret under the column.
+type SyntaxError = lexer.SyntaxError
+
+// InvalidUnmarshalError is returned when Unmarshal is not given a non-nil pointer.
+type InvalidUnmarshalError struct {
+	Type reflect.Type
+}
+
+func (e *InvalidUnmarshalError) Error() string {
+	if e.Type == nil {
+		return "toyjson: Unmarshal(nil)"
+	}
+	if e.Type.Kind() != reflect.Ptr {
+		return "toyjson: Unmarshal(non-pointer " + e.Type.String() + ")"
+	}
+	return "toyjson: Unmarshal(nil " + e.Type.String() + ")"
+}
+
+// Unmarshal decodes the JSON document in data into the value pointed to by v.
+//
+// Objects go into structs (matched by json tag or field name, case-insensitively as a fallback)
+// or into maps with string keys; arrays go into slices and arrays; null zeroes pointers, maps,
+// slices and interfaces. An empty interface receives map[string]interface{}, []interface{},
+// float64, string, bool or nil. Unknown object keys are ignored.
+// Like parser.Parse, trailing commas and duplicate keys are errors, nesting is limited to parser.MaxDepth,
+// and malformed input gives a *SyntaxError.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	d := &decodeState{data: data, lexer: lexer.NewLexerWithString(string(data))}
+	if err := d.peek(); err != nil {
+		return err
+	}
+	if err := d.value(rv); err != nil {
+		return err
+	}
+	if err := d.peek(); err != nil {
+		return err
+	}
+	if d.tok != nil {
+		return lexer.NewSyntaxError(d.tok.Pos(), "unexpected %s after top-level value", d.tok.Type())
+	}
+	return nil
+}
+
+type decodeState struct {
+	data   []byte // the whole input, or nil when decoding from a stream
+	lexer  *lexer.Lexer
+	tok    *lexer.Token // nil at the end of input
+	peeked bool         // tok holds the next token
+	end    int          // end offset of the last consumed token
+	path   []string     // segments below $, already formatted as .name or [i]
+	depth  int          // arrays and objects currently open
+	record *encodeState // receives consumed tokens while capturing a raw value from a stream
+}
+
+// peek reads the next token into d.tok unless it is already there.
+func (d *decodeState) peek() error {
+	if d.peeked {
+		return nil
+	}
+	tok, err := d.lexer.GetNextToken()
+	if err == io.EOF {
+		tok, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+	d.tok, d.peeked = tok, true
+	return nil
+}
+
+// consume drops the current token without reading the one after it,
+// so a stream is not read past the end of a top-level value.
+func (d *decodeState) consume() {
+	d.end = d.tok.End()
+	if d.record != nil {
+		d.record.token(d.tok)
+	}
+	d.tok, d.peeked = nil, false
+}
+
+// next consumes the current token and peeks at the following one.
+func (d *decodeState) next() error {
+	d.consume()
+	return d.peek()
+}
+
+func (d *decodeState) is(t lexer.TokenType) bool {
+	return d.tok != nil && d.tok.Type() == t
+}
+
+func (d *decodeState) unexpected(expected string) error {
+	if d.tok == nil {
+		return lexer.NewSyntaxError(d.lexer.Pos(), "unexpected end of input, expected %s", expected)
+	}
+	return lexer.NewSyntaxError(d.tok.Pos(), "unexpected %s, expected %s", d.tok.Type(), expected)
+}
+
+func (d *decodeState) currentPath() string {
+	return "$" + strings.Join(d.path, "")
+}
+
+func (d *decodeState) typeError(value string, t reflect.Type) error {
+	return &UnmarshalTypeError{Value: value, Type: t, Path: d.currentPath(), Offset: d.tok.Offset()}
+}
+
+// pathKey formats an object key as a path segment, quoting keys that are not identifiers.
+func pathKey(key string) string {
+	for i, r := range key {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return "[" + strconv.Quote(key) + "]"
+		}
+	}
+	if key == "" {
+		return `[""]`
+	}
+	return "." + key
+}
+
+// indirect walks down v allocating pointers as needed until it reaches a non-pointer.
+// It stops early at an Unmarshaler. With null, it stops at the last pointer so it can be set to nil.
+func indirect(v reflect.Value, null bool) (Unmarshaler, reflect.Value) {
+	if v.Kind() != reflect.Ptr && v.Type().Name() != "" && v.CanAddr() {
+		v = v.Addr()
+	}
+	for {
+		if v.Kind() == reflect.Interface && !v.IsNil() {
+			e := v.Elem()
+			if e.Kind() == reflect.Ptr && !e.IsNil() && (!null || e.Elem().Kind() == reflect.Ptr) {
+				v = e
+				continue
+			}
+		}
+		if v.Kind() != reflect.Ptr {
+			break
+		}
+		if null && v.CanSet() {
+			break
+		}
+		if v.Elem().Kind() == reflect.Interface && v.Elem().Elem() == v {
+			v = v.Elem()
+			break
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		if v.Type().NumMethod() > 0 && v.CanInterface() {
+			if u, ok := v.Interface().(Unmarshaler); ok {
+				return u, reflect.Value{}
+			}
+		}
+		v = v.Elem()
+	}
+	return nil, v
+}
+
+func (d *decodeState) value(v reflect.Value) error {
+	if d.tok == nil {
+		return d.unexpected("value")
+	}
+
+	u, pv := indirect(v, d.is(lexer.TokenNull))
+	if u != nil {
+		raw, err := d.raw()
+		if err != nil {
+			return err
+		}
+		return u.UnmarshalJSON(raw)
+	}
+	v = pv
+
+	switch d.tok.Type() {
+	case lexer.TokenLeftBrace, lexer.TokenLeftBracket:
+		if d.depth == parser.MaxDepth {
+			return lexer.NewSyntaxError(d.tok.Pos(), "exceeded max depth of %d", parser.MaxDepth)
+		}
+		d.depth++
+		defer func() { d.depth-- }()
+		if d.is(lexer.TokenLeftBrace) {
+			return d.object(v)
+		}
+		return d.array(v)
+	case lexer.TokenNull:
+		switch v.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			v.Set(reflect.Zero(v.Type()))
+		}
+	case lexer.TokenTrue, lexer.TokenFalse:
+		b := d.is(lexer.TokenTrue)
+		switch {
+		case v.Kind() == reflect.Bool:
+			v.SetBool(b)
+		case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+			v.Set(reflect.ValueOf(b))
+		default:
+			return d.typeError("bool", v.Type())
+		}
+	case lexer.TokenString:
+		s := d.tok.String()
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(s)
+		case v.Kind() == reflect.Interface && v.NumMethod() == 0:
+			v.Set(reflect.ValueOf(s))
+		default:
+			return d.typeError("string", v.Type())
+		}
+	case lexer.TokenNumber:
+		if err := d.number(v); err != nil {
+			return err
+		}
+	default:
+		return d.unexpected("value")
+	}
+	d.consume()
+	return nil
+}
+
+func (d *decodeState) number(v reflect.Value) error {
+	lit := d.tok.Literal()
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(lit, 10, 64)
+		if err != nil || v.OverflowInt(n) {
+			return d.typeError("number "+lit, v.Type())
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(lit, 10, 64)
+		if err != nil || v.OverflowUint(n) {
+			return d.typeError("number "+lit, v.Type())
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		if v.OverflowFloat(d.tok.Number()) {
+			return d.typeError("number "+lit, v.Type())
+		}
+		v.SetFloat(d.tok.Number())
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.typeError("number", v.Type())
+		}
+		v.Set(reflect.ValueOf(d.tok.Number()))
+	default:
+		return d.typeError("number", v.Type())
+	}
+	return nil
+}
+
+func (d *decodeState) array(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.typeError("array", v.Type())
+		}
+		var a []interface{}
+		av := reflect.ValueOf(&a).Elem()
+		if err := d.array(av); err != nil {
+			return err
+		}
+		v.Set(av)
+		return nil
+	case reflect.Slice, reflect.Array:
+	default:
+		return d.typeError("array", v.Type())
+	}
+
+	if err := d.next(); err != nil {
+		return err
+	}
+	i := 0
+	if !d.is(lexer.TokenRightBracket) {
+		for {
+			d.path = append(d.path, "["+strconv.Itoa(i)+"]")
+			var err error
+			switch {
+			case v.Kind() == reflect.Slice:
+				if i >= v.Cap() {
+					v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+				}
+				v.SetLen(i + 1)
+				v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+				err = d.value(v.Index(i))
+			case i < v.Len():
+				err = d.value(v.Index(i))
+			default:
+				// elements beyond the end of a Go array are discarded
+				err = d.skip()
+			}
+			if err != nil {
+				return err
+			}
+			d.path = d.path[:len(d.path)-1]
+			i++
+
+			if err := d.peek(); err != nil {
+				return err
+			}
+			if d.is(lexer.TokenRightBracket) {
+				break
+			}
+			if !d.is(lexer.TokenComma) {
+				return d.unexpected("',' or ']'")
+			}
+			comma := d.tok.Pos()
+			if err := d.next(); err != nil {
+				return err
+			}
+			if d.is(lexer.TokenRightBracket) {
+				return lexer.NewSyntaxError(comma, "trailing comma in array")
+			}
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if i == 0 && v.IsNil() {
+			v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+		}
+		v.SetLen(i)
+	case reflect.Array:
+		for ; i < v.Len(); i++ {
+			v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+		}
+	}
+	d.consume()
+	return nil
+}
+
+func (d *decodeState) object(v reflect.Value) error {
+	var fields []field
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.typeError("object", v.Type())
+		}
+		m := map[string]interface{}{}
+		mv := reflect.ValueOf(m)
+		if err := d.object(mv); err != nil {
+			return err
+		}
+		v.Set(mv)
+		return nil
+	case reflect.Map:
+		kt := v.Type().Key()
+		if kt.Kind() != reflect.String && !reflect.PtrTo(kt).Implements(reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()) {
+			return d.typeError("object", v.Type())
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+	case reflect.Struct:
+		fields = cachedFields(v.Type())
+	default:
+		return d.typeError("object", v.Type())
+	}
+
+	if err := d.next(); err != nil {
+		return err
+	}
+	if d.is(lexer.TokenRightBrace) {
+		d.consume()
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for {
+		if !d.is(lexer.TokenString) {
+			return d.unexpected("string key")
+		}
+		key := d.tok.String()
+		keyPos := d.tok.Pos()
+		if seen[key] {
+			return lexer.NewSyntaxError(keyPos, "duplicate key %q", key)
+		}
+		seen[key] = true
+		if err := d.next(); err != nil {
+			return err
+		}
+		if !d.is(lexer.TokenColon) {
+			return d.unexpected("':'")
+		}
+		if err := d.next(); err != nil {
+			return err
+		}
+
+		d.path = append(d.path, pathKey(key))
+		var err error
+		switch v.Kind() {
+		case reflect.Map:
+			err = d.mapEntry(v, key, keyPos.Offset)
+		default:
+			if f := fieldByName(fields, key); f != nil {
+				err = d.value(fieldByIndex(v, f.index))
+			} else {
+				err = d.skip()
+			}
+		}
+		if err != nil {
+			return err
+		}
+		d.path = d.path[:len(d.path)-1]
+
+		if err := d.peek(); err != nil {
+			return err
+		}
+		if d.is(lexer.TokenRightBrace) {
+			d.consume()
+			return nil
+		}
+		if !d.is(lexer.TokenComma) {
+			return d.unexpected("',' or '}'")
+		}
+		comma := d.tok.Pos()
+		if err := d.next(); err != nil {
+			return err
+		}
+		if d.is(lexer.TokenRightBrace) {
+			return lexer.NewSyntaxError(comma, "trailing comma in object")
+		}
+	}
+}
+
+func (d *decodeState) mapEntry(m reflect.Value, key string, keyOffset int) error {
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := d.value(elem); err != nil {
+		return err
+	}
+
+	kt := m.Type().Key()
+	var kv reflect.Value
+	if kt.Kind() == reflect.String {
+		kv = reflect.ValueOf(key).Convert(kt)
+	} else {
+		kv = reflect.New(kt)
+		if err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return &UnmarshalTypeError{Value: "string " + strconv.Quote(key), Type: kt, Path: d.currentPath(), Offset: keyOffset}
+		}
+		kv = kv.Elem()
+	}
+	m.SetMapIndex(kv, elem)
+	return nil
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex that allocates nil embedded pointers on the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// raw consumes one value and returns its JSON text.
+// When decoding from a stream, the consumed tokens are written out again in compact form.
+func (d *decodeState) raw() ([]byte, error) {
+	if d.data != nil {
+		start := d.tok.Offset()
+		if err := d.skip(); err != nil {
+			return nil, err
+		}
+		return d.data[start:d.end], nil
+	}
+
+	d.record = &encodeState{}
+	defer func() { d.record = nil }()
+	if err := d.skip(); err != nil {
+		return nil, err
+	}
+	return d.record.Bytes(), nil
+}
+
+// skip consumes one value without storing it, checking its syntax.
+func (d *decodeState) skip() error {
+	var discard interface{}
+	path := d.path
+	err := d.value(reflect.ValueOf(&discard).Elem())
+	d.path = path
+	return err
+}
@@ -0,0 +1,207 @@
+package toyjson
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+type Base struct {
+	ID   int `json:"id"`
+	Note string
+}
+
+type Inner struct {
+	Name string `json:"name"`
+}
+
+type Item struct {
+	Base
+	*Inner
+	Tags    []string          `json:"tags,omitempty"`
+	Attrs   map[string]int    `json:"attrs"`
+	Next    *Item             `json:"next"`
+	Any     interface{}       `json:"any"`
+	Pair    [2]int            `json:"pair"`
+	Skipped string            `json:"-"`
+	Labels  map[string]string `json:"labels"`
+	hidden  int
+}
+
+// upper stores strings in upper case through UnmarshalJSON.
+type upper string
+
+func (u *upper) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*u = upper(strings.ToUpper(s))
+	return nil
+}
+
+func TestUnmarshal(t *testing.T) {
+	input := `{
+		"id": 7, "note": "case-insensitive", "name": "first",
+		"tags": ["a", "b"], "attrs": {"x": 1, "y": -2},
+		"next": {"id": 8, "next": null, "pair": [5]},
+		"any": {"list": [1, "two", true, null]},
+		"pair": [1, 2, 3],
+		"Skipped": "no", "unknown": {"deep": [1, {}]},
+		"labels": null
+	}`
+
+	got := Item{Labels: map[string]string{"old": "x"}}
+	if err := Unmarshal([]byte(input), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Item{
+		Base:  Base{ID: 7, Note: "case-insensitive"},
+		Inner: &Inner{Name: "first"},
+		Tags:  []string{"a", "b"},
+		Attrs: map[string]int{"x": 1, "y": -2},
+		Next:  &Item{Base: Base{ID: 8}, Pair: [2]int{5, 0}},
+		Any:   map[string]interface{}{"list": []interface{}{1.0, "two", true, nil}},
+		Pair:  [2]int{1, 2},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("value wrong.\ngot= %#v\nwant=%#v", got, expected)
+	}
+}
+
+func TestUnmarshalScalars(t *testing.T) {
+	var (
+		i   int8
+		u   uint64
+		f   float32
+		s   string
+		b   bool
+		p   *int
+		any interface{}
+		up  upper
+		ups []upper
+	)
+	tests := []struct {
+		input    string
+		target   interface{}
+		expected interface{}
+	}{
+		{"-128", &i, int8(-128)},
+		{"18446744073709551615", &u, uint64(18446744073709551615)},
+		{"1.5e2", &f, float32(150)},
+		{`"あ"`, &s, "あ"},
+		{"true", &b, true},
+		{"42", &p, 42},
+		{"[1, {}]", &any, []interface{}{1.0, map[string]interface{}{}}},
+		{`"shout"`, &up, upper("SHOUT")},
+		{`["a", "b"]`, &ups, []upper{"A", "B"}},
+	}
+
+	for _, tt := range tests {
+		if err := Unmarshal([]byte(tt.input), tt.target); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+			continue
+		}
+		got := reflect.ValueOf(tt.target).Elem()
+		if got.Kind() == reflect.Ptr {
+			got = got.Elem()
+		}
+		if !reflect.DeepEqual(got.Interface(), tt.expected) {
+			t.Errorf("%s: value wrong. got=%#v, want=%#v", tt.input, got.Interface(), tt.expected)
+		}
+	}
+
+	p = new(int)
+	if err := Unmarshal([]byte("null"), &p); err != nil || p != nil {
+		t.Errorf("null should set the pointer to nil. got=%v, err=%v", p, err)
+	}
+}
+
+func TestUnmarshalTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected UnmarshalTypeError
+	}{
+		{`{"id": "7"}`, UnmarshalTypeError{"string", reflect.TypeOf(0), "$.id", 7}},
+		{`{"next": {"pair": [1, true]}}`, UnmarshalTypeError{"bool", reflect.TypeOf(0), "$.next.pair[1]", 22}},
+		{`{"attrs": {"a b": 1.5}}`, UnmarshalTypeError{"number 1.5", reflect.TypeOf(0), `$.attrs["a b"]`, 18}},
+		{`{"tags": {}}`, UnmarshalTypeError{"object", reflect.TypeOf([]string{}), "$.tags", 9}},
+		{`[]`, UnmarshalTypeError{"array", reflect.TypeOf(Item{}), "$", 0}},
+	}
+
+	for _, tt := range tests {
+		var item Item
+		err := Unmarshal([]byte(tt.input), &item)
+		var typeErr *UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			t.Errorf("%s: expected *UnmarshalTypeError. got=%v", tt.input, err)
+			continue
+		}
+		if *typeErr != tt.expected {
+			t.Errorf("%s: error wrong. got=%+v, want=%+v", tt.input, *typeErr, tt.expected)
+		}
+	}
+
+	var n int8
+	err := Unmarshal([]byte("300"), &n)
+	expected := "toyjson: cannot unmarshal number 300 into Go value of type int8 at $ (offset 0)"
+	if err == nil || err.Error() != expected {
+		t.Errorf("error wrong. got=%v, want=%q", err, expected)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		target   interface{}
+		expected string
+	}{
+		{"1", nil, "toyjson: Unmarshal(nil)"},
+		{"1", 0, "toyjson: Unmarshal(non-pointer int)"},
+		{"1", (*int)(nil), "toyjson: Unmarshal(nil *int)"},
+		{"", new(int), "1:1: unexpected end of input, expected value"},
+		{"1 2", new(int), "1:3: unexpected number after top-level value"},
+		{"[1,]", new([]int), "1:3: trailing comma in array"},
+		{`{"a": 1,}`, new(map[string]int), "1:8: trailing comma in object"},
+		{`{"a": 1, "a": 2}`, new(map[string]int), `1:10: duplicate key "a"`},
+		{`{"a" 1}`, new(Item), "1:6: unexpected number, expected ':'"},
+		{`[1 2]`, new(interface{}), "1:4: unexpected number, expected ',' or ']'"},
+	}
+
+	for _, tt := range tests {
+		err := Unmarshal([]byte(tt.input), tt.target)
+		if err == nil {
+			t.Errorf("%q: expected error %q", tt.input, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestUnmarshalMaxDepth(t *testing.T) {
+	var v interface{}
+	input := strings.Repeat("[", parser.MaxDepth) + strings.Repeat("]", parser.MaxDepth)
+	if err := Unmarshal([]byte(input), &v); err != nil {
+		t.Fatalf("unexpected error at max depth: %v", err)
+	}
+
+	// far deeper than MaxDepth, which would overflow the stack without the limit;
+	// an Unmarshaler reaches the limit while its raw value is captured
+	input = strings.Repeat("[", 1000000) + strings.Repeat("]", 1000000)
+	for _, target := range []interface{}{new(interface{}), new([]interface{}), new(upper)} {
+		err := Unmarshal([]byte(input), target)
+		var syntaxErr *SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Fatalf("%T: expected *SyntaxError. got=%T %v", target, err, err)
+		}
+		if expected := "1:10001: exceeded max depth of 10000"; err.Error() != expected {
+			t.Errorf("%T: error wrong. got=%q, want=%q", target, err.Error(), expected)
+		}
+	}
+}
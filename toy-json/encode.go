@@ -0,0 +1,608 @@
+package toyjson
+
+import (
+	"bytes"
+	"encoding"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/tMinamiii/various-parser/toy-json/lexer"
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// Marshaler is implemented by types that encode themselves as JSON.
+// The output must be one valid JSON value. It is copied as written, with its whitespace
+// replaced by the layout of the surrounding output; canonical output parses it and writes
+// it again, since RFC 8785 requires numbers and strings in normal form.
+type Marshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// UnsupportedTypeError is returned for Go types that have no JSON form, such as channels and funcs.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "toyjson: unsupported type: " + e.Type.String()
+}
+
+// UnsupportedValueError is returned for values that have no JSON form, such as NaN.
+type UnsupportedValueError struct {
+	Value reflect.Value
+	Str   string
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return "toyjson: unsupported value: " + e.Str
+}
+
+// MarshalerError wraps an error from a MarshalJSON method or invalid JSON it returned.
+type MarshalerError struct {
+	Type reflect.Type
+	Err  error
+}
+
+func (e *MarshalerError) Error() string {
+	return "toyjson: error calling MarshalJSON for type " + e.Type.String() + ": " + e.Err.Error()
+}
+
+func (e *MarshalerError) Unwrap() error {
+	return e.Err
+}
+
+// Marshal returns the compact JSON encoding of v.
+//
+// Structs become objects using the same json tags as Unmarshal, with omitempty honoured.
+// Map keys are sorted. parser.Value trees are written as they are, keeping member order.
+func Marshal(v interface{}) ([]byte, error) {
+	e := &encodeState{}
+	if err := e.marshal(v); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+// MarshalIndent is Marshal with each element on its own line, starting with prefix
+// and indented by one copy of indent per nesting level.
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	e := &encodeState{prefix: prefix, indent: indent}
+	if err := e.marshal(v); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+// MarshalCanonical returns the RFC 8785 (JCS) encoding of v: no whitespace, object members
+// sorted by their UTF-16 code units, numbers written as ECMAScript does, and minimal string escaping.
+// The same value always gives the same bytes, so the output is suitable for hashing and signing.
+func MarshalCanonical(v interface{}) ([]byte, error) {
+	e := &encodeState{canonical: true}
+	if err := e.marshal(v); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+// Encoder writes JSON values to an output stream, one per line.
+type Encoder struct {
+	w          io.Writer
+	prefix     string
+	indent     string
+	canonical  bool
+	escapeHTML bool
+}
+
+// NewEncoder returns a compact encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// SetIndent switches to indented output. Empty prefix and indent mean compact output.
+func (enc *Encoder) SetIndent(prefix, indent string) {
+	enc.prefix = prefix
+	enc.indent = indent
+}
+
+// SetCanonical switches to RFC 8785 output. It overrides SetIndent and SetEscapeHTML.
+func (enc *Encoder) SetCanonical(on bool) {
+	enc.canonical = on
+}
+
+// SetEscapeHTML makes strings escape <, > and & (and U+2028, U+2029)
+// so the output can be embedded in HTML <script> tags.
+func (enc *Encoder) SetEscapeHTML(on bool) {
+	enc.escapeHTML = on
+}
+
+// Encode writes the JSON encoding of v followed by a newline.
+func (enc *Encoder) Encode(v interface{}) error {
+	e := &encodeState{canonical: enc.canonical}
+	if !enc.canonical {
+		e.prefix, e.indent, e.escapeHTML = enc.prefix, enc.indent, enc.escapeHTML
+	}
+	if err := e.marshal(v); err != nil {
+		return err
+	}
+	e.WriteByte('\n')
+	_, err := enc.w.Write(e.Bytes())
+	return err
+}
+
+type encodeState struct {
+	bytes.Buffer
+	prefix     string
+	indent     string
+	canonical  bool
+	escapeHTML bool
+	depth      int
+	ptrLevel   int
+	ptrSeen    map[seenKey]struct{}
+}
+
+// cycles are only looked for this deep into pointers, maps and slices,
+// so ordinary values do not pay for the bookkeeping
+const startDetectingCyclesAfter = 1000
+
+type seenKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	nullType          = reflect.TypeOf(parser.Null{})
+	objectType        = reflect.TypeOf(parser.Object{})
+)
+
+func (e *encodeState) marshal(v interface{}) error {
+	return e.value(reflect.ValueOf(v))
+}
+
+// newline starts a new line at the current depth when indenting.
+func (e *encodeState) newline() {
+	if e.prefix == "" && e.indent == "" {
+		return
+	}
+	e.WriteByte('\n')
+	e.WriteString(e.prefix)
+	for i := 0; i < e.depth; i++ {
+		e.WriteString(e.indent)
+	}
+}
+
+func (e *encodeState) value(v reflect.Value) error {
+	if !v.IsValid() {
+		e.WriteString("null")
+		return nil
+	}
+
+	if v.Kind() != reflect.Interface && v.Type().Implements(marshalerType) {
+		return e.marshaler(v)
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(marshalerType) {
+		return e.marshaler(v.Addr())
+	}
+
+	switch v.Type() {
+	case nullType:
+		e.WriteString("null")
+		return nil
+	case objectType:
+		return e.object(v.Interface().(parser.Object))
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		e.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if e.canonical {
+			return e.float(v, float64(v.Int()), 64)
+		}
+		e.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if e.canonical {
+			return e.float(v, float64(v.Uint()), 64)
+		}
+		e.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32:
+		return e.float(v, v.Float(), 32)
+	case reflect.Float64:
+		return e.float(v, v.Float(), 64)
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Interface:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		return e.value(v.Elem())
+	case reflect.Ptr:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		return e.reference(v, func() error { return e.value(v.Elem()) })
+	case reflect.Struct:
+		return e.structValue(v)
+	case reflect.Map:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		return e.reference(v, func() error { return e.mapValue(v) })
+	case reflect.Slice:
+		if v.IsNil() {
+			e.WriteString("null")
+			return nil
+		}
+		return e.reference(v, func() error { return e.array(v) })
+	case reflect.Array:
+		return e.array(v)
+	default:
+		return &UnsupportedTypeError{Type: v.Type()}
+	}
+	return nil
+}
+
+// reference encodes a pointer, map or slice, failing if it is already being encoded further up.
+func (e *encodeState) reference(v reflect.Value, encode func() error) error {
+	e.ptrLevel++
+	defer func() { e.ptrLevel-- }()
+	if e.ptrLevel <= startDetectingCyclesAfter {
+		return encode()
+	}
+
+	key := seenKey{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if _, ok := e.ptrSeen[key]; ok {
+		return &UnsupportedValueError{Value: v, Str: "encountered a cycle via " + v.Type().String()}
+	}
+	if e.ptrSeen == nil {
+		e.ptrSeen = make(map[seenKey]struct{})
+	}
+	e.ptrSeen[key] = struct{}{}
+	defer delete(e.ptrSeen, key)
+	return encode()
+}
+
+func (e *encodeState) marshaler(v reflect.Value) error {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		e.WriteString("null")
+		return nil
+	}
+	b, err := v.Interface().(Marshaler).MarshalJSON()
+	if err != nil {
+		return &MarshalerError{Type: v.Type(), Err: err}
+	}
+	pv, err := parser.Parse(bytes.NewReader(b))
+	if err != nil {
+		return &MarshalerError{Type: v.Type(), Err: err}
+	}
+	if e.canonical {
+		return e.value(reflect.ValueOf(pv))
+	}
+	return e.reindent(b)
+}
+
+// reindent writes the valid JSON in b, compact or indented like the rest of the output.
+// Tokens are copied as written, so numbers keep all their digits and strings their escapes.
+func (e *encodeState) reindent(b []byte) error {
+	l := lexer.NewLexer(lexer.NewScanner(bytes.NewReader(b)))
+	opened := false // the last token was '[' or '{', so an empty one stays on one line
+	for {
+		tok, err := l.GetNextToken()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		text := b[tok.Offset():tok.End()]
+
+		closing := tok.Type() == lexer.TokenRightBracket || tok.Type() == lexer.TokenRightBrace
+		if opened && !closing {
+			e.depth++
+			e.newline()
+		}
+		switch {
+		case tok.Type() == lexer.TokenLeftBracket || tok.Type() == lexer.TokenLeftBrace:
+			e.Write(text)
+			opened = true
+			continue
+		case closing:
+			if !opened {
+				e.depth--
+				e.newline()
+			}
+			e.Write(text)
+		case tok.Type() == lexer.TokenComma:
+			e.Write(text)
+			e.newline()
+		case tok.Type() == lexer.TokenColon:
+			e.Write(text)
+			if e.indent != "" || e.prefix != "" {
+				e.WriteByte(' ')
+			}
+		case !e.escapeHTML || tok.Type() != lexer.TokenString:
+			e.Write(text)
+		default:
+			e.htmlSafe(text)
+		}
+		opened = false
+	}
+}
+
+// htmlSafe writes a string token with <, >, & and the line and paragraph separators escaped.
+func (e *encodeState) htmlSafe(text []byte) {
+	for _, r := range string(text) {
+		switch r {
+		case '<', '>', '&':
+			e.WriteString(`\u00`)
+			e.WriteByte(hex[r>>4])
+			e.WriteByte(hex[r&0xf])
+		case '\u2028', '\u2029':
+			e.WriteString(`\u202`)
+			e.WriteByte(hex[r&0xf])
+		default:
+			e.WriteRune(r)
+		}
+	}
+}
+
+// float writes f the way ECMAScript's Number.prototype.toString does,
+// which is also what RFC 8785 requires.
+func (e *encodeState) float(v reflect.Value, f float64, bits int) error {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return &UnsupportedValueError{Value: v, Str: strconv.FormatFloat(f, 'g', -1, bits)}
+	}
+	if f == 0 {
+		// -0 is written as 0
+		e.WriteByte('0')
+		return nil
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	b := strconv.AppendFloat(nil, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	e.Write(b)
+	return nil
+}
+
+const hex = "0123456789abcdef"
+
+func (e *encodeState) string(s string) {
+	e.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				e.WriteByte('\\')
+				e.WriteByte(c)
+			case c == '\b':
+				e.WriteString(`\b`)
+			case c == '\f':
+				e.WriteString(`\f`)
+			case c == '\n':
+				e.WriteString(`\n`)
+			case c == '\r':
+				e.WriteString(`\r`)
+			case c == '\t':
+				e.WriteString(`\t`)
+			case c < 0x20 || e.escapeHTML && (c == '<' || c == '>' || c == '&'):
+				e.WriteString(`\u00`)
+				e.WriteByte(hex[c>>4])
+				e.WriteByte(hex[c&0xf])
+			default:
+				e.WriteByte(c)
+			}
+			i++
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			e.WriteString(`\ufffd`)
+		case e.escapeHTML && (r == '\u2028' || r == '\u2029'):
+			e.WriteString(`\u202`)
+			e.WriteByte(hex[r&0xf])
+		default:
+			e.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	e.WriteByte('"')
+}
+
+// token writes a lexer token back out as JSON text.
+func (e *encodeState) token(tok *lexer.Token) {
+	switch tok.Type() {
+	case lexer.TokenString:
+		e.string(tok.String())
+	case lexer.TokenNumber:
+		e.WriteString(tok.Literal())
+	default:
+		e.WriteString(strings.Trim(tok.Type().String(), "'"))
+	}
+}
+
+func (e *encodeState) array(v reflect.Value) error {
+	if v.Len() == 0 {
+		e.WriteString("[]")
+		return nil
+	}
+
+	e.WriteByte('[')
+	e.depth++
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+		e.newline()
+		if err := e.value(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	e.depth--
+	e.newline()
+	e.WriteByte(']')
+	return nil
+}
+
+// member is an object member waiting to be written.
+type member struct {
+	key   string
+	value reflect.Value
+}
+
+// members writes an object. Keys are sorted by UTF-16 code units in canonical mode.
+func (e *encodeState) members(ms []member) error {
+	if len(ms) == 0 {
+		e.WriteString("{}")
+		return nil
+	}
+	if e.canonical {
+		sort.SliceStable(ms, func(i, j int) bool {
+			return lessUTF16(ms[i].key, ms[j].key)
+		})
+	}
+
+	e.WriteByte('{')
+	e.depth++
+	for i, m := range ms {
+		if i > 0 {
+			e.WriteByte(',')
+		}
+		e.newline()
+		e.string(m.key)
+		e.WriteByte(':')
+		if e.indent != "" || e.prefix != "" {
+			e.WriteByte(' ')
+		}
+		if err := e.value(m.value); err != nil {
+			return err
+		}
+	}
+	e.depth--
+	e.newline()
+	e.WriteByte('}')
+	return nil
+}
+
+func (e *encodeState) object(o parser.Object) error {
+	ms := make([]member, len(o.Members))
+	for i, m := range o.Members {
+		ms[i] = member{m.Key, reflect.ValueOf(m.Value)}
+	}
+	return e.members(ms)
+}
+
+func (e *encodeState) structValue(v reflect.Value) error {
+	var ms []member
+	for _, f := range cachedFields(v.Type()) {
+		fv, ok := fieldByIndexRead(v, f.index)
+		if !ok || f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		ms = append(ms, member{f.name, fv})
+	}
+	return e.members(ms)
+}
+
+func (e *encodeState) mapValue(v reflect.Value) error {
+	if v.IsNil() {
+		e.WriteString("null")
+		return nil
+	}
+
+	ms := make([]member, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		k := iter.Key()
+		var key string
+		switch {
+		case k.Kind() == reflect.String:
+			key = k.String()
+		case k.Type().Implements(textMarshalerType):
+			if k.Kind() == reflect.Ptr && k.IsNil() {
+				key = ""
+				break
+			}
+			b, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return &MarshalerError{Type: k.Type(), Err: err}
+			}
+			key = string(b)
+		default:
+			return &UnsupportedTypeError{Type: v.Type()}
+		}
+		ms = append(ms, member{key, iter.Value()})
+	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].key < ms[j].key })
+	return e.members(ms)
+}
+
+// fieldByIndexRead is reflect.Value.FieldByIndex that reports false at a nil embedded pointer.
+func fieldByIndexRead(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// lessUTF16 compares strings by their UTF-16 code units as RFC 8785 requires.
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
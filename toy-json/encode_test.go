@@ -0,0 +1,333 @@
+package toyjson
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// point writes itself as a two-element array through MarshalJSON.
+type point struct {
+	X, Y int
+}
+
+func (p point) MarshalJSON() ([]byte, error) {
+	return []byte(`[ ` + itoa(p.X) + ` , ` + itoa(p.Y) + ` ]`), nil
+}
+
+func itoa(n int) string {
+	b, _ := Marshal(n)
+	return string(b)
+}
+
+// raw returns its own text from MarshalJSON.
+type raw string
+
+func (r raw) MarshalJSON() ([]byte, error) {
+	return []byte(r), nil
+}
+
+// cycle can point back at itself.
+type cycle struct {
+	Next *cycle
+}
+
+type broken struct{}
+
+func (broken) MarshalJSON() ([]byte, error) {
+	return []byte(`{"a":`), nil
+}
+
+type shape struct {
+	Name   string            `json:"name"`
+	Points []point           `json:"points"`
+	Meta   map[string]string `json:"meta,omitempty"`
+	Scale  float64           `json:"scale,omitempty"`
+	Owner  *Inner            `json:"owner"`
+	Base
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{-12, "-12"},
+		{uint8(200), "200"},
+		{1.5, "1.5"},
+		{float32(0.1), "0.1"},
+		{1e21, "1e+21"},
+		{1e-7, "1e-7"},
+		{"a\"b\\c\n\u0001<>&", `"a\"b\\c\n\u0001<>&"`},
+		{[]int{}, "[]"},
+		{[]int(nil), "null"},
+		{[2]bool{true}, "[true,false]"},
+		{map[string]int{"b": 2, "a": 1}, `{"a":1,"b":2}`},
+		{
+			shape{Name: "tri", Points: []point{{1, 2}, {3, 4}}, Base: Base{ID: 9}},
+			`{"name":"tri","points":[[1,2],[3,4]],"owner":null,"id":9,"Note":""}`,
+		},
+		{
+			parser.Object{Members: []parser.Member{{Key: "z", Value: parser.Null{}}, {Key: "a", Value: parser.Array{parser.Number(1)}}}},
+			`{"z":null,"a":[1]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		b, err := Marshal(tt.input)
+		if err != nil {
+			t.Errorf("%#v: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if string(b) != tt.expected {
+			t.Errorf("%#v: output wrong. got=%s, want=%s", tt.input, b, tt.expected)
+		}
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	v := map[string]interface{}{"a": []interface{}{1, map[string]int{}}, "b": shape{Name: "x", Meta: map[string]string{"k": "v"}}}
+	expected := `{
+>  "a": [
+>    1,
+>    {}
+>  ],
+>  "b": {
+>    "name": "x",
+>    "points": null,
+>    "meta": {
+>      "k": "v"
+>    },
+>    "owner": null,
+>    "id": 0,
+>    "Note": ""
+>  }
+>}`
+
+	b, err := MarshalIndent(v, ">", "  ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(b) != expected {
+		t.Errorf("output wrong.\ngot=\n%s\nwant=\n%s", b, expected)
+	}
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		// number samples from RFC 8785 and ECMAScript
+		{1e30, "1e+30"},
+		{4.50, "4.5"},
+		{2e-3, "0.002"},
+		{0.000000000000000000000000001, "1e-27"},
+		{math.Copysign(0, -1), "0"},
+		{333333333.33333329, "333333333.3333333"},
+		{1e20, "100000000000000000000"},
+		{5e-324, "5e-324"},
+		{int64(9007199254740993), "9007199254740992"},
+		// float32 keeps its own shortest form rather than that of the widened float64
+		{float32(0.1), "0.1"},
+		{float32(-1.5e-7), "-1.5e-7"},
+		{float32(16777217), "16777216"},
+		{float32(math.MaxFloat32), "3.4028235e+38"},
+		{[]float32{1e21, 1e20}, "[1e+21,100000000000000000000]"},
+		{"\u2028<\x7f\x1f", "\"\u2028<\x7f\\u001f\""},
+		// key order from RFC 8785 section 3.2.3: UTF-16 code units, not code points
+		{
+			map[string]int{"\u20ac": 1, "\r": 2, "\ufb33": 3, "1": 4, "\U0001f600": 5, "\u0080": 6, "\u00f6": 7},
+			"{\"\\r\":2,\"1\":4,\"\u0080\":6,\"\u00f6\":7,\"\u20ac\":1,\"\U0001f600\":5,\"\ufb33\":3}",
+		},
+		{
+			shape{Name: "n", Base: Base{ID: 1}, Points: []point{{1, 2}}},
+			`{"Note":"","id":1,"name":"n","owner":null,"points":[[1,2]]}`,
+		},
+		{
+			parser.Object{Members: []parser.Member{{Key: "b", Value: parser.Number(1)}, {Key: "a", Value: parser.Bool(true)}}},
+			`{"a":true,"b":1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		b, err := MarshalCanonical(tt.input)
+		if err != nil {
+			t.Errorf("%#v: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if string(b) != tt.expected {
+			t.Errorf("%#v: output wrong. got=%s, want=%s", tt.input, b, tt.expected)
+		}
+	}
+}
+
+func TestMarshalerOutput(t *testing.T) {
+	big := raw(`12345678901234567890`)
+	doc := raw(` { "a" : 1.10 , "b" : [ "\u00e9<" ] } `)
+
+	tests := []struct {
+		marshal  func() ([]byte, error)
+		expected string
+	}{
+		// compact output copies the bytes, so numbers are not rounded through float64
+		{func() ([]byte, error) { return Marshal(big) }, `12345678901234567890`},
+		{func() ([]byte, error) { return Marshal(map[string]raw{"n": big}) }, `{"n":12345678901234567890}`},
+		{func() ([]byte, error) { return Marshal(doc) }, `{"a":1.10,"b":["\u00e9<"]}`},
+		{
+			func() ([]byte, error) {
+				var buf bytes.Buffer
+				enc := NewEncoder(&buf)
+				enc.SetEscapeHTML(true)
+				err := enc.Encode(doc)
+				return buf.Bytes(), err
+			},
+			"{\"a\":1.10,\"b\":[\"\\u00e9\\u003c\"]}\n",
+		},
+		// indented output lays the tokens out again but still copies them as written
+		{func() ([]byte, error) { return MarshalIndent(doc, "", " ") }, "{\n \"a\": 1.10,\n \"b\": [\n  \"\\u00e9<\"\n ]\n}"},
+		{func() ([]byte, error) { return MarshalIndent(map[string]raw{"n": big}, "", "  ") }, "{\n  \"n\": 12345678901234567890\n}"},
+		{
+			func() ([]byte, error) {
+				return MarshalIndent([]raw{`{"a":[],"b":{},"c":[{"d":[1,2]}]}`}, ">", "\t")
+			},
+			"[\n>\t{\n>\t\t\"a\": [],\n>\t\t\"b\": {},\n>\t\t\"c\": [\n>\t\t\t{\n>\t\t\t\t\"d\": [\n>\t\t\t\t\t1,\n>\t\t\t\t\t2\n>\t\t\t\t]\n>\t\t\t}\n>\t\t]\n>\t}\n>]",
+		},
+		// canonical output reformats the value, as RFC 8785 normalizes numbers
+		{func() ([]byte, error) { return MarshalCanonical(big) }, `12345678901234567000`},
+	}
+
+	for i, tt := range tests {
+		b, err := tt.marshal()
+		if err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+			continue
+		}
+		if string(b) != tt.expected {
+			t.Errorf("%d: output wrong. got=%s, want=%s", i, b, tt.expected)
+		}
+	}
+}
+
+func TestMarshalCycle(t *testing.T) {
+	c := &cycle{}
+	c.Next = c
+	m := map[string]interface{}{}
+	m["self"] = m
+	s := []interface{}{nil}
+	s[0] = s
+
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{c, "toyjson: unsupported value: encountered a cycle via *toyjson.cycle"},
+		{m, "toyjson: unsupported value: encountered a cycle via map[string]interface {}"},
+		{s, "toyjson: unsupported value: encountered a cycle via []interface {}"},
+	}
+	for _, tt := range tests {
+		_, err := Marshal(tt.input)
+		var valueErr *UnsupportedValueError
+		if !errors.As(err, &valueErr) || err.Error() != tt.expected {
+			t.Errorf("%T: error wrong. got=%v, want=%q", tt.input, err, tt.expected)
+		}
+	}
+
+	// deep but acyclic values are still fine
+	var deep *cycle
+	for i := 0; i < 2*startDetectingCyclesAfter; i++ {
+		deep = &cycle{Next: deep}
+	}
+	if _, err := Marshal(deep); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode("<a href=\"x\">&\u2028"); err != nil {
+		t.Fatal(err)
+	}
+	enc.SetEscapeHTML(true)
+	if err := enc.Encode("<a href=\"x\">&\u2028"); err != nil {
+		t.Fatal(err)
+	}
+	enc.SetIndent("", "\t")
+	if err := enc.Encode([]int{1}); err != nil {
+		t.Fatal(err)
+	}
+	enc.SetCanonical(true)
+	if err := enc.Encode(map[string]string{"b": "<", "a": ""}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "\"<a href=\\\"x\\\">&\u2028\"\n" +
+		"\"\\u003ca href=\\\"x\\\"\\u003e\\u0026\\u2028\"\n" +
+		"[\n\t1\n]\n" +
+		"{\"a\":\"\",\"b\":\"<\"}\n"
+	if buf.String() != expected {
+		t.Errorf("output wrong.\ngot= %q\nwant=%q", buf.String(), expected)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	in := Item{
+		Base:  Base{ID: 3, Note: "n"},
+		Inner: &Inner{Name: "in"},
+		Tags:  []string{"x"},
+		Attrs: map[string]int{"k": 1},
+		Any:   map[string]interface{}{"f": 1.25, "l": []interface{}{"s", false, nil}},
+		Pair:  [2]int{4, 5},
+	}
+	b, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out Item
+	if err := Unmarshal(b, &out); err != nil {
+		t.Fatalf("%s: %v", b, err)
+	}
+	again, err := Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, again) {
+		t.Errorf("round trip changed the output.\nfirst= %s\nsecond=%s", b, again)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	tests := []struct {
+		input    interface{}
+		expected string
+	}{
+		{math.NaN(), "toyjson: unsupported value: NaN"},
+		{[]float64{math.Inf(1)}, "toyjson: unsupported value: +Inf"},
+		{make(chan int), "toyjson: unsupported type: chan int"},
+		{map[int]string{1: "a"}, "toyjson: unsupported type: map[int]string"},
+		{broken{}, "toyjson: error calling MarshalJSON for type toyjson.broken: 1:6: unexpected end of input, expected value"},
+	}
+
+	for _, tt := range tests {
+		_, err := Marshal(tt.input)
+		if err == nil {
+			t.Errorf("%#v: expected error %q", tt.input, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%#v: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+
+	var merr *MarshalerError
+	if _, err := Marshal(broken{}); !errors.As(err, &merr) || !strings.Contains(merr.Type.String(), "broken") {
+		t.Errorf("expected *MarshalerError. got=%v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package toyjson
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// field is a struct field as seen by JSON, possibly promoted from an embedded struct.
+type field struct {
+	name      string
+	index     []int // for reflect.Value.FieldByIndex, through embedded structs
+	typ       reflect.Type
+	omitEmpty bool
+	tagged    bool // the name comes from a json tag
+}
+
+var fieldCache sync.Map // reflect.Type -> []field
+
+// cachedFields returns the JSON fields of the struct type t in declaration order.
+func cachedFields(t reflect.Type) []field {
+	if f, ok := fieldCache.Load(t); ok {
+		return f.([]field)
+	}
+	f, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return f.([]field)
+}
+
+// parseTag splits a json tag into its name and options.
+func parseTag(tag string) (string, []string) {
+	parts := strings.Split(tag, ",")
+	return parts[0], parts[1:]
+}
+
+// typeFields collects the fields of t following the rules of encoding/json.
+// Fields of embedded structs are promoted unless they have a json name.
+// When several fields get the same name, the shallowest wins, then a tagged one;
+// if that still leaves more than one, all of them are dropped.
+func typeFields(t reflect.Type) []field {
+	var fields []field
+
+	type queued struct {
+		typ   reflect.Type
+		index []int
+	}
+	current := []queued{}
+	next := []queued{{typ: t}}
+	visited := map[reflect.Type]bool{}
+
+	for len(next) > 0 {
+		current, next = next, nil
+		for _, q := range current {
+			if visited[q.typ] {
+				continue
+			}
+			visited[q.typ] = true
+
+			for i := 0; i < q.typ.NumField(); i++ {
+				sf := q.typ.Field(i)
+				ft := sf.Type
+				if sf.Anonymous && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				// unexported embedded pointers cannot be allocated, so only embedded struct values are followed
+				if !sf.IsExported() && !(sf.Anonymous && sf.Type.Kind() == reflect.Struct) {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts := parseTag(tag)
+				index := append(append([]int{}, q.index...), i)
+
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, queued{typ: ft, index: index})
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+
+				f := field{name: name, index: index, typ: sf.Type, tagged: name != ""}
+				if name == "" {
+					f.name = sf.Name
+				}
+				for _, opt := range opts {
+					if opt == "omitempty" {
+						f.omitEmpty = true
+					}
+				}
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	// pick one winner per name
+	sort.SliceStable(fields, func(i, j int) bool {
+		if fields[i].name != fields[j].name {
+			return fields[i].name < fields[j].name
+		}
+		if len(fields[i].index) != len(fields[j].index) {
+			return len(fields[i].index) < len(fields[j].index)
+		}
+		return fields[i].tagged && !fields[j].tagged
+	})
+	var result []field
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		group := fields[i:j]
+		if len(group) == 1 || len(group[0].index) < len(group[1].index) || group[0].tagged && !group[1].tagged {
+			result = append(result, group[0])
+		}
+		i = j
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].index, result[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return result
+}
+
+// fieldByName finds the field for a JSON key, preferring an exact match over a case-insensitive one.
+func fieldByName(fields []field, key string) *field {
+	var fold *field
+	for i := range fields {
+		if fields[i].name == key {
+			return &fields[i]
+		}
+		if fold == nil && strings.EqualFold(fields[i].name, key) {
+			fold = &fields[i]
+		}
+	}
+	return fold
+}
@@ -0,0 +1,93 @@
+package jsonpath
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// TestConformance runs the cases in testdata/*.json. The files use the layout of
+// the JSONPath Compliance Test Suite, so its cts.json can be dropped in as it is:
+// each test has a selector and either invalid_selector, or a document with the
+// expected result (or results, when member order makes several acceptable)
+// and optionally result_paths.
+func TestConformance(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no testdata")
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		suite, err := parser.Parse(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+
+		tests, _ := member(suite, "tests").(parser.Array)
+		for _, tc := range tests {
+			name := strings.TrimSuffix(filepath.Base(file), ".json") + "/" + string(member(tc, "name").(parser.String))
+			t.Run(name, func(t *testing.T) {
+				runCase(t, tc)
+			})
+		}
+	}
+}
+
+func runCase(t *testing.T, tc parser.Value) {
+	selector := string(member(tc, "selector").(parser.String))
+	p, err := Compile(selector)
+	if member(tc, "invalid_selector") == parser.Bool(true) {
+		if err == nil {
+			t.Fatalf("%q: expected an error", selector)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("%q: unexpected error: %v", selector, err)
+	}
+
+	nodes := p.Query(member(tc, "document"))
+	got := make(parser.Array, len(nodes))
+	for i, n := range nodes {
+		got[i] = n.Value
+	}
+
+	if results, ok := member(tc, "results").(parser.Array); ok {
+		for _, r := range results {
+			if parser.Equal(got, r) {
+				return
+			}
+		}
+		t.Fatalf("%q: result wrong. got=%v, want one of %v", selector, got, results)
+	}
+	if expected := member(tc, "result"); !parser.Equal(got, expected) {
+		t.Fatalf("%q: result wrong. got=%v, want=%v", selector, got, expected)
+	}
+
+	if paths, ok := member(tc, "result_paths").(parser.Array); ok {
+		locations := make(parser.Array, len(nodes))
+		for i, n := range nodes {
+			locations[i] = parser.String(n.Location())
+		}
+		if !parser.Equal(locations, paths) {
+			t.Fatalf("%q: locations wrong. got=%v, want=%v", selector, locations, paths)
+		}
+	}
+}
+
+func member(v parser.Value, key string) parser.Value {
+	o, _ := v.(parser.Object)
+	m, _ := o.Get(key)
+	return m
+}
@@ -0,0 +1,316 @@
+package jsonpath
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// evalContext is what a filter expression is evaluated against.
+type evalContext struct {
+	root    parser.Value
+	current parser.Value // the node bound to @
+}
+
+// result holds the outcome of an expression; which field is used depends on its type.
+type result struct {
+	value   parser.Value // nil is Nothing
+	logical bool
+	nodes   []Node
+}
+
+type expr interface {
+	eval(c *evalContext) result
+}
+
+// kind is what the parser knows about an expression, to check it is used where it may be.
+type kind int8
+
+const (
+	kindLiteral kind = iota
+	kindSingularQuery
+	kindQuery
+	kindLogical
+	kindValueFunc
+	kindLogicalFunc
+	kindNodesFunc
+)
+
+type literal struct {
+	value parser.Value
+}
+
+func (e literal) eval(c *evalContext) result {
+	return result{value: e.value}
+}
+
+type queryExpr struct {
+	query *query
+}
+
+func (e queryExpr) eval(c *evalContext) result {
+	start := c.root
+	if e.query.relative {
+		start = c.current
+	}
+	return result{nodes: e.query.apply(Node{Value: start, index: -1}, c.root)}
+}
+
+// valueOf turns a singular query into the value it selects, or Nothing.
+type valueOf struct {
+	query queryExpr
+}
+
+func (e valueOf) eval(c *evalContext) result {
+	nodes := e.query.eval(c).nodes
+	if len(nodes) == 1 {
+		return result{value: nodes[0].Value}
+	}
+	return result{}
+}
+
+// exists is true when a nodes-typed expression selects anything.
+type exists struct {
+	expr expr
+}
+
+func (e exists) eval(c *evalContext) result {
+	return result{logical: len(e.expr.eval(c).nodes) > 0}
+}
+
+type notExpr struct {
+	expr expr
+}
+
+func (e notExpr) eval(c *evalContext) result {
+	return result{logical: !e.expr.eval(c).logical}
+}
+
+type andExpr []expr
+
+func (e andExpr) eval(c *evalContext) result {
+	for _, x := range e {
+		if !x.eval(c).logical {
+			return result{}
+		}
+	}
+	return result{logical: true}
+}
+
+type orExpr []expr
+
+func (e orExpr) eval(c *evalContext) result {
+	for _, x := range e {
+		if x.eval(c).logical {
+			return result{logical: true}
+		}
+	}
+	return result{}
+}
+
+type comparison struct {
+	op          string
+	left, right expr
+}
+
+func (e comparison) eval(c *evalContext) result {
+	a, b := e.left.eval(c).value, e.right.eval(c).value
+	var ok bool
+	switch e.op {
+	case "==":
+		ok = equal(a, b)
+	case "!=":
+		ok = !equal(a, b)
+	case "<":
+		ok = less(a, b)
+	case "<=":
+		ok = less(a, b) || equal(a, b)
+	case ">":
+		ok = less(b, a)
+	case ">=":
+		ok = less(b, a) || equal(a, b)
+	}
+	return result{logical: ok}
+}
+
+// equal compares two values where nil is Nothing, which only equals itself.
+func equal(a, b parser.Value) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return parser.Equal(a, b)
+}
+
+// less orders numbers and strings; every other pair is unordered.
+func less(a, b parser.Value) bool {
+	switch x := a.(type) {
+	case parser.Number:
+		y, ok := b.(parser.Number)
+		return ok && x < y
+	case parser.String:
+		y, ok := b.(parser.String)
+		return ok && x < y
+	}
+	return false
+}
+
+type funcCall struct {
+	fn   *function
+	args []expr
+}
+
+func (e funcCall) eval(c *evalContext) result {
+	args := make([]result, len(e.args))
+	for i, a := range e.args {
+		args[i] = a.eval(c)
+	}
+	return e.fn.call(args)
+}
+
+// paramType is the declared type of a function parameter or result.
+type paramType int8
+
+const (
+	valueType paramType = iota
+	logicalType
+	nodesType
+)
+
+type function struct {
+	params []paramType
+	result paramType
+	call   func(args []result) result
+}
+
+// functions are the function extensions defined by RFC 9535.
+var functions = map[string]*function{
+	"length": {
+		params: []paramType{valueType},
+		result: valueType,
+		call: func(args []result) result {
+			switch v := args[0].value.(type) {
+			case parser.String:
+				return result{value: parser.Number(utf8.RuneCountInString(string(v)))}
+			case parser.Array:
+				return result{value: parser.Number(len(v))}
+			case parser.Object:
+				return result{value: parser.Number(len(v.Members))}
+			}
+			return result{}
+		},
+	},
+	"count": {
+		params: []paramType{nodesType},
+		result: valueType,
+		call: func(args []result) result {
+			return result{value: parser.Number(len(args[0].nodes))}
+		},
+	},
+	"match": {
+		params: []paramType{valueType, valueType},
+		result: logicalType,
+		call: func(args []result) result {
+			return result{logical: regexpMatch(args[0].value, args[1].value, true)}
+		},
+	},
+	"search": {
+		params: []paramType{valueType, valueType},
+		result: logicalType,
+		call: func(args []result) result {
+			return result{logical: regexpMatch(args[0].value, args[1].value, false)}
+		},
+	},
+	"value": {
+		params: []paramType{nodesType},
+		result: valueType,
+		call: func(args []result) result {
+			if len(args[0].nodes) == 1 {
+				return result{value: args[0].nodes[0].Value}
+			}
+			return result{}
+		},
+	},
+}
+
+func regexpMatch(s, pattern parser.Value, whole bool) bool {
+	str, ok := s.(parser.String)
+	if !ok {
+		return false
+	}
+	p, ok := pattern.(parser.String)
+	if !ok {
+		return false
+	}
+	re := compileIRegexp(string(p), whole)
+	return re != nil && re.MatchString(string(str))
+}
+
+// literalRegexp is match() or search() with a literal pattern, compiled once with the query.
+// Patterns taken from the document are compiled on each call instead, so that
+// running a query never keeps anything around.
+type literalRegexp struct {
+	arg expr
+	re  *regexp.Regexp // nil when the pattern is not a valid I-Regexp
+}
+
+func (e literalRegexp) eval(c *evalContext) result {
+	s, ok := e.arg.eval(c).value.(parser.String)
+	return result{logical: ok && e.re != nil && e.re.MatchString(string(s))}
+}
+
+// compileIRegexp compiles an I-Regexp (RFC 9485), anchored at both ends when whole is set.
+// It returns nil when pattern is not a valid I-Regexp.
+func compileIRegexp(pattern string, whole bool) *regexp.Regexp {
+	src, ok := translateIRegexp(pattern)
+	if !ok {
+		return nil
+	}
+	if whole {
+		src = `^(?:` + src + `)$`
+	}
+	re, _ := regexp.Compile(src)
+	return re
+}
+
+// translateIRegexp rewrites an I-Regexp in Go syntax. The two differ in that
+// '.' excludes only \n and \r, '^' and '$' are ordinary characters,
+// and escapes other than single characters and \p{..} are not allowed.
+func translateIRegexp(pattern string) (string, bool) {
+	var b strings.Builder
+	inClass := false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '\\':
+			if i+1 == len(pattern) || !strings.ContainsRune(`\()*+-.?[]^{|}nrtpP`, rune(pattern[i+1])) {
+				return "", false
+			}
+			b.WriteByte(c)
+			b.WriteByte(pattern[i+1])
+			i++
+		case inClass:
+			switch c {
+			case ']':
+				inClass = false
+			case '[':
+				b.WriteByte('\\')
+			}
+			b.WriteByte(c)
+		case c == '[':
+			inClass = true
+			b.WriteByte(c)
+		case c == '.':
+			b.WriteString(`[^\n\r]`)
+		case c == '^', c == '$':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '(' && i+1 < len(pattern) && pattern[i+1] == '?':
+			return "", false
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), !inClass
+}
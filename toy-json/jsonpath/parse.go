@@ -0,0 +1,635 @@
+package jsonpath
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/tMinamiii/various-parser/toy-json/lexer"
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// indices and slice bounds must fit in an I-JSON number
+const maxIndex = 1<<53 - 1
+
+type pathParser struct {
+	src string
+	pos int
+}
+
+func (p *pathParser) errorAt(offset int, format string, args ...interface{}) error {
+	pos := lexer.Position{Offset: offset, Line: 1, Column: 1}
+	for _, r := range p.src[:offset] {
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return lexer.NewSyntaxError(pos, format, args...)
+}
+
+func (p *pathParser) errorf(format string, args ...interface{}) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+// unexpected reports the character at the current position, or the end of the query.
+func (p *pathParser) unexpected(expected string) error {
+	if p.pos >= len(p.src) {
+		return p.errorf("unexpected end of query, expected %s", expected)
+	}
+	r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+	return p.errorf("unexpected %q, expected %s", r, expected)
+}
+
+func (p *pathParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *pathParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// accept consumes s if the query continues with it.
+func (p *pathParser) accept(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *pathParser) expect(s string) error {
+	if !p.accept(s) {
+		return p.unexpected("'" + s + "'")
+	}
+	return nil
+}
+
+func (p *pathParser) parse() (*query, error) {
+	if p.peek() != '$' {
+		return nil, p.unexpected("'$'")
+	}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.unexpected("segment")
+	}
+	return q, nil
+}
+
+// parseQuery reads $ or @ and the segments after it.
+func (p *pathParser) parseQuery() (*query, error) {
+	q := &query{relative: p.peek() == '@'}
+	p.pos++
+	for {
+		save := p.pos
+		p.skipSpace()
+		if c := p.peek(); c != '.' && c != '[' {
+			p.pos = save
+			return q, nil
+		}
+		s, err := p.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, s)
+	}
+}
+
+func (p *pathParser) parseSegment() (segment, error) {
+	if p.accept("[") {
+		sels, err := p.parseSelectors()
+		return segment{selectors: sels}, err
+	}
+
+	p.pos++ // '.'
+	var s segment
+	if p.accept(".") {
+		s.descendant = true
+		if p.accept("[") {
+			sels, err := p.parseSelectors()
+			s.selectors = sels
+			return s, err
+		}
+	}
+	if p.accept("*") {
+		s.selectors = []selector{wildcardSelector{}}
+		return s, nil
+	}
+	name := p.parseMemberName()
+	if name == "" {
+		return s, p.unexpected("member name or '*'")
+	}
+	s.selectors = []selector{nameSelector{name}}
+	return s, nil
+}
+
+// parseMemberName reads the name after a '.' shorthand; it cannot start with a digit.
+func (p *pathParser) parseMemberName() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		nameChar := r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' ||
+			r >= 0x80 && r != utf8.RuneError ||
+			p.pos > start && '0' <= r && r <= '9'
+		if !nameChar {
+			break
+		}
+		p.pos += size
+	}
+	return p.src[start:p.pos]
+}
+
+// parseSelectors reads a comma-separated list of selectors up to the closing ']'.
+func (p *pathParser) parseSelectors() ([]selector, error) {
+	var sels []selector
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+		p.skipSpace()
+		if p.accept("]") {
+			return sels, nil
+		}
+		if !p.accept(",") {
+			return nil, p.unexpected("',' or ']'")
+		}
+	}
+}
+
+func (p *pathParser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return nameSelector{s}, err
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+		e, err := p.parseLogical()
+		return filterSelector{e}, err
+	case c == '-' || c == ':' || '0' <= c && c <= '9':
+		return p.parseIndexOrSlice()
+	}
+	return nil, p.unexpected("selector")
+}
+
+func (p *pathParser) parseIndexOrSlice() (selector, error) {
+	var bounds [3]*int64
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			if !p.accept(":") {
+				break
+			}
+			p.skipSpace()
+		}
+		if c := p.peek(); c == '-' || '0' <= c && c <= '9' {
+			n, err := p.parseInt()
+			if err != nil {
+				return nil, err
+			}
+			bounds[i] = &n
+			p.skipSpace()
+		}
+		if i == 0 && p.peek() != ':' {
+			return indexSelector{*bounds[0]}, nil
+		}
+	}
+
+	s := sliceSelector{start: bounds[0], end: bounds[1], step: 1}
+	if bounds[2] != nil {
+		s.step = *bounds[2]
+	}
+	return s, nil
+}
+
+// parseInt reads an index: no leading zeros, no -0, and within ±(2^53-1).
+func (p *pathParser) parseInt() (int64, error) {
+	start := p.pos
+	p.accept("-")
+	digits := p.pos
+	for '0' <= p.peek() && p.peek() <= '9' {
+		p.pos++
+	}
+	text := p.src[start:p.pos]
+	switch {
+	case p.pos == digits:
+		return 0, p.unexpected("digit")
+	case p.src[digits] == '0' && (p.pos-digits > 1 || digits > start):
+		return 0, p.errorAt(start, "invalid integer %s", text)
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n > maxIndex || n < -maxIndex {
+		return 0, p.errorAt(start, "integer %s out of range", text)
+	}
+	return n, nil
+}
+
+// parseString reads a single- or double-quoted string literal.
+func (p *pathParser) parseString() (string, error) {
+	start := p.pos
+	quote := rune(p.src[p.pos])
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) {
+			return "", p.errorAt(start, "unterminated string")
+		}
+		r, size := utf8.DecodeRuneInString(p.src[p.pos:])
+		switch {
+		case r == quote:
+			p.pos++
+			return b.String(), nil
+		case r == '\\':
+			r, err := p.parseEscape(quote)
+			if err != nil {
+				return "", err
+			}
+			b.WriteRune(r)
+			continue
+		case r < 0x20:
+			return "", p.errorf("invalid control character %U in string", r)
+		case r == utf8.RuneError && size == 1:
+			return "", p.errorf("invalid UTF-8 encoding")
+		}
+		b.WriteRune(r)
+		p.pos += size
+	}
+}
+
+var escapes = map[byte]rune{'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', '/': '/', '\\': '\\'}
+
+func (p *pathParser) parseEscape(quote rune) (rune, error) {
+	start := p.pos
+	p.pos++ // '\'
+	if p.pos >= len(p.src) {
+		return 0, p.errorAt(start, "unterminated escape sequence")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	if r, ok := escapes[c]; ok {
+		return r, nil
+	}
+	if rune(c) == quote {
+		return quote, nil
+	}
+	if c != 'u' {
+		return 0, p.errorAt(start, "invalid escape sequence \\%c", c)
+	}
+
+	r, ok := p.hex4()
+	if !ok {
+		return 0, p.errorAt(start, "invalid \\u escape")
+	}
+	switch {
+	case utf16.IsSurrogate(r) && r < 0xdc00:
+		if !p.accept(`\u`) {
+			return 0, p.errorAt(start, "unpaired surrogate %s", p.src[start:p.pos])
+		}
+		lo, ok := p.hex4()
+		if !ok || lo < 0xdc00 || lo > 0xdfff {
+			return 0, p.errorAt(start, "unpaired surrogate %s", p.src[start:start+6])
+		}
+		return utf16.DecodeRune(r, lo), nil
+	case utf16.IsSurrogate(r):
+		return 0, p.errorAt(start, "unpaired surrogate %s", p.src[start:p.pos])
+	}
+	return r, nil
+}
+
+func (p *pathParser) hex4() (rune, bool) {
+	if p.pos+4 > len(p.src) {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	p.pos += 4
+	return rune(n), true
+}
+
+// parseLogical reads a filter expression that must evaluate to true or false.
+func (p *pathParser) parseLogical() (expr, error) {
+	start := p.pos
+	e, k, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	return p.asLogical(start, e, k)
+}
+
+func (p *pathParser) parseOr() (expr, kind, error) {
+	return p.parseBinary("||", p.parseAnd, func(list []expr) expr { return orExpr(list) })
+}
+
+func (p *pathParser) parseAnd() (expr, kind, error) {
+	return p.parseBinary("&&", p.parseBasic, func(list []expr) expr { return andExpr(list) })
+}
+
+// parseBinary reads operands joined by op. A lone operand is returned as it is,
+// since a function argument may be a query or a literal rather than a logical expression.
+func (p *pathParser) parseBinary(op string, operand func() (expr, kind, error), join func([]expr) expr) (expr, kind, error) {
+	start := p.pos
+	e, k, err := operand()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var list []expr
+	for {
+		save := p.pos
+		p.skipSpace()
+		if !p.accept(op) {
+			p.pos = save
+			break
+		}
+		if list == nil {
+			first, err := p.asLogical(start, e, k)
+			if err != nil {
+				return nil, 0, err
+			}
+			list = []expr{first}
+		}
+		p.skipSpace()
+		start = p.pos
+		e, k, err := operand()
+		if err != nil {
+			return nil, 0, err
+		}
+		next, err := p.asLogical(start, e, k)
+		if err != nil {
+			return nil, 0, err
+		}
+		list = append(list, next)
+	}
+	if list == nil {
+		return e, k, nil
+	}
+	return join(list), kindLogical, nil
+}
+
+var comparisonOps = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// parseBasic reads a negation, a parenthesized expression, a comparison or a single operand.
+func (p *pathParser) parseBasic() (expr, kind, error) {
+	if p.accept("!") {
+		p.skipSpace()
+		start := p.pos
+		var e expr
+		var k kind
+		var err error
+		if c := p.peek(); c == '(' {
+			e, k, err = p.parseBasic()
+		} else if c == '@' || c == '$' || 'a' <= c && c <= 'z' {
+			e, k, err = p.parsePrimary()
+			if err == nil && k == kindLiteral {
+				err = p.errorAt(start, "'!' needs a query, a function or a parenthesized expression")
+			}
+		} else {
+			err = p.unexpected("query, function or '('")
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		if e, err = p.asLogical(start, e, k); err != nil {
+			return nil, 0, err
+		}
+		return notExpr{e}, kindLogical, nil
+	}
+
+	if p.accept("(") {
+		p.skipSpace()
+		e, err := p.parseLogical()
+		if err != nil {
+			return nil, 0, err
+		}
+		p.skipSpace()
+		if err := p.expect(")"); err != nil {
+			return nil, 0, err
+		}
+		return e, kindLogical, nil
+	}
+
+	start := p.pos
+	left, lk, err := p.parsePrimary()
+	if err != nil {
+		return nil, 0, err
+	}
+	save := p.pos
+	p.skipSpace()
+	var op string
+	for _, o := range comparisonOps {
+		if p.accept(o) {
+			op = o
+			break
+		}
+	}
+	if op == "" {
+		p.pos = save
+		return left, lk, nil
+	}
+
+	if left, err = p.asComparable(start, left, lk); err != nil {
+		return nil, 0, err
+	}
+	p.skipSpace()
+	start = p.pos
+	right, rk, err := p.parsePrimary()
+	if err != nil {
+		return nil, 0, err
+	}
+	if right, err = p.asComparable(start, right, rk); err != nil {
+		return nil, 0, err
+	}
+	return comparison{op: op, left: left, right: right}, kindLogical, nil
+}
+
+// parsePrimary reads a literal, a query or a function call.
+func (p *pathParser) parsePrimary() (expr, kind, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, 0, err
+		}
+		if q.singular() {
+			return queryExpr{q}, kindSingularQuery, nil
+		}
+		return queryExpr{q}, kindQuery, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		return literal{parser.String(s)}, kindLiteral, err
+	case c == '-' || '0' <= c && c <= '9':
+		n, err := p.parseNumber()
+		return literal{n}, kindLiteral, err
+	case 'a' <= c && c <= 'z':
+		start := p.pos
+		for c := p.peek(); 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '_'; c = p.peek() {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.peek() == '(' {
+			return p.parseCall(start, name)
+		}
+		switch name {
+		case "true":
+			return literal{parser.Bool(true)}, kindLiteral, nil
+		case "false":
+			return literal{parser.Bool(false)}, kindLiteral, nil
+		case "null":
+			return literal{parser.Null{}}, kindLiteral, nil
+		}
+		return nil, 0, p.errorAt(start, "unknown literal %s", name)
+	}
+	return nil, 0, p.unexpected("literal, query or function")
+}
+
+// parseNumber reads a JSON number literal; -0 is allowed here, unlike in indices.
+func (p *pathParser) parseNumber() (parser.Value, error) {
+	start := p.pos
+	p.accept("-")
+	digits := func() int {
+		from := p.pos
+		for '0' <= p.peek() && p.peek() <= '9' {
+			p.pos++
+		}
+		return p.pos - from
+	}
+
+	intStart := p.pos
+	n := digits()
+	switch {
+	case n == 0:
+		return nil, p.unexpected("digit")
+	case n > 1 && p.src[intStart] == '0':
+		return nil, p.errorAt(start, "invalid number: leading zero")
+	}
+	if p.accept(".") && digits() == 0 {
+		return nil, p.unexpected("digit")
+	}
+	if c := p.peek(); c == 'e' || c == 'E' {
+		p.pos++
+		if !p.accept("+") {
+			p.accept("-")
+		}
+		if digits() == 0 {
+			return nil, p.unexpected("digit")
+		}
+	}
+
+	f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return nil, p.errorAt(start, "number %s out of range", p.src[start:p.pos])
+	}
+	return parser.Number(f), nil
+}
+
+func (p *pathParser) parseCall(start int, name string) (expr, kind, error) {
+	fn, ok := functions[name]
+	if !ok {
+		return nil, 0, p.errorAt(start, "unknown function %s()", name)
+	}
+	p.pos++ // '('
+
+	var args []expr
+	p.skipSpace()
+	for len(args) == 0 && p.peek() != ')' || len(args) > 0 && p.accept(",") {
+		p.skipSpace()
+		argStart := p.pos
+		e, k, err := p.parseOr()
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(args) >= len(fn.params) {
+			return nil, 0, p.errorAt(argStart, "too many arguments to %s()", name)
+		}
+		if e, err = p.asParam(argStart, fn.params[len(args)], e, k); err != nil {
+			return nil, 0, err
+		}
+		args = append(args, e)
+		p.skipSpace()
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, 0, err
+	}
+	if len(args) < len(fn.params) {
+		return nil, 0, p.errorAt(start, "not enough arguments to %s()", name)
+	}
+
+	k := kindValueFunc
+	switch fn.result {
+	case logicalType:
+		k = kindLogicalFunc
+	case nodesType:
+		k = kindNodesFunc
+	}
+	if name == "match" || name == "search" {
+		if l, ok := args[1].(literal); ok {
+			if pattern, ok := l.value.(parser.String); ok {
+				return literalRegexp{args[0], compileIRegexp(string(pattern), name == "match")}, k, nil
+			}
+		}
+	}
+	return funcCall{fn: fn, args: args}, k, nil
+}
+
+func (p *pathParser) asParam(start int, t paramType, e expr, k kind) (expr, error) {
+	switch t {
+	case valueType:
+		return p.asComparable(start, e, k)
+	case logicalType:
+		return p.asLogical(start, e, k)
+	}
+	if k != kindQuery && k != kindSingularQuery && k != kindNodesFunc {
+		return nil, p.errorAt(start, "argument must be a query")
+	}
+	return e, nil
+}
+
+// asLogical checks e can be a test: a logical expression, or a nodes-typed one tested for existence.
+func (p *pathParser) asLogical(start int, e expr, k kind) (expr, error) {
+	switch k {
+	case kindLogical, kindLogicalFunc:
+		return e, nil
+	case kindQuery, kindSingularQuery, kindNodesFunc:
+		return exists{e}, nil
+	case kindLiteral:
+		return nil, p.errorAt(start, "literal must be compared")
+	}
+	return nil, p.errorAt(start, "function result must be compared")
+}
+
+// asComparable checks e has a single value: a literal, a singular query or a value-typed function.
+func (p *pathParser) asComparable(start int, e expr, k kind) (expr, error) {
+	switch k {
+	case kindLiteral, kindValueFunc:
+		return e, nil
+	case kindSingularQuery:
+		return valueOf{e.(queryExpr)}, nil
+	case kindQuery:
+		return nil, p.errorAt(start, "non-singular query is not comparable")
+	}
+	return nil, p.errorAt(start, "logical expression is not comparable")
+}
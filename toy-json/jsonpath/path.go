@@ -0,0 +1,296 @@
+// Package jsonpath implements JSONPath queries (RFC 9535) over parser.Value trees.
+package jsonpath
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// Path is a compiled JSONPath query. It is safe for concurrent use.
+type Path struct {
+	src   string
+	query *query
+}
+
+// Compile parses a JSONPath query such as $.store.book[?@.price < 10].title.
+// Errors are *lexer.SyntaxError positioned in expr.
+func Compile(expr string) (*Path, error) {
+	p := &pathParser{src: expr}
+	q, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Path{src: expr, query: q}, nil
+}
+
+// MustCompile is like Compile but panics if expr is not a valid query.
+func MustCompile(expr string) *Path {
+	p, err := Compile(expr)
+	if err != nil {
+		panic(fmt.Sprintf("jsonpath: Compile(%q): %v", expr, err))
+	}
+	return p
+}
+
+// Query compiles expr and runs it against doc.
+func Query(expr string, doc parser.Value) ([]Node, error) {
+	p, err := Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Query(doc), nil
+}
+
+// String returns the source text of the query.
+func (p *Path) String() string {
+	return p.src
+}
+
+// Query returns the nodes selected from doc, in the order the RFC defines.
+func (p *Path) Query(doc parser.Value) []Node {
+	return p.query.apply(Node{Value: doc, index: -1}, doc)
+}
+
+// Values is like Query but returns only the selected values.
+func (p *Path) Values(doc parser.Value) []parser.Value {
+	nodes := p.Query(doc)
+	values := make([]parser.Value, len(nodes))
+	for i, n := range nodes {
+		values[i] = n.Value
+	}
+	return values
+}
+
+// Node is a value selected by a query, together with where it was found.
+type Node struct {
+	Value  parser.Value
+	parent *Node
+	name   string // member name, when index is -1
+	index  int
+}
+
+// Location returns the normalized path of the node, such as $['store']['book'][0].
+func (n Node) Location() string {
+	var chain []*Node
+	for p := &n; p.parent != nil; p = p.parent {
+		chain = append(chain, p)
+	}
+
+	var b strings.Builder
+	b.WriteString("$")
+	for i := len(chain) - 1; i >= 0; i-- {
+		if chain[i].index >= 0 {
+			fmt.Fprintf(&b, "[%d]", chain[i].index)
+		} else {
+			writeName(&b, chain[i].name)
+		}
+	}
+	return b.String()
+}
+
+func writeName(b *strings.Builder, name string) {
+	b.WriteString("['")
+	for _, r := range name {
+		switch r {
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '\\':
+			b.WriteString(`\\`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteString("']")
+}
+
+// children returns the members of an object or the elements of an array, in order.
+func children(n *Node) []Node {
+	switch v := n.Value.(type) {
+	case parser.Object:
+		nodes := make([]Node, len(v.Members))
+		for i, m := range v.Members {
+			nodes[i] = Node{Value: m.Value, parent: n, name: m.Key, index: -1}
+		}
+		return nodes
+	case parser.Array:
+		nodes := make([]Node, len(v))
+		for i, e := range v {
+			nodes[i] = Node{Value: e, parent: n, index: i}
+		}
+		return nodes
+	}
+	return nil
+}
+
+// query is $ or @ followed by segments.
+type query struct {
+	relative bool // starts at @ rather than $
+	segments []segment
+}
+
+// singular reports whether the query selects at most one node:
+// only child segments, each with a single name or index.
+func (q *query) singular() bool {
+	for _, s := range q.segments {
+		if s.descendant || len(s.selectors) != 1 {
+			return false
+		}
+		switch s.selectors[0].(type) {
+		case nameSelector, indexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (q *query) apply(start Node, root parser.Value) []Node {
+	nodes := []Node{start}
+	for _, s := range q.segments {
+		var out []Node
+		for i := range nodes {
+			out = s.apply(&nodes[i], root, out)
+		}
+		nodes = out
+	}
+	return nodes
+}
+
+// segment is [...] or ..[...], with the shorthands .name, .*, ..name and ..* in between.
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+func (s segment) apply(n *Node, root parser.Value, out []Node) []Node {
+	for _, sel := range s.selectors {
+		out = sel.apply(n, root, out)
+	}
+	if s.descendant {
+		nodes := children(n)
+		for i := range nodes {
+			out = s.apply(&nodes[i], root, out)
+		}
+	}
+	return out
+}
+
+// selector picks children of a node and appends them to out.
+type selector interface {
+	apply(n *Node, root parser.Value, out []Node) []Node
+}
+
+type nameSelector struct {
+	name string
+}
+
+func (s nameSelector) apply(n *Node, root parser.Value, out []Node) []Node {
+	if o, ok := n.Value.(parser.Object); ok {
+		if v, ok := o.Get(s.name); ok {
+			out = append(out, Node{Value: v, parent: n, name: s.name, index: -1})
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) apply(n *Node, root parser.Value, out []Node) []Node {
+	return append(out, children(n)...)
+}
+
+type indexSelector struct {
+	index int64
+}
+
+func (s indexSelector) apply(n *Node, root parser.Value, out []Node) []Node {
+	a, ok := n.Value.(parser.Array)
+	if !ok {
+		return out
+	}
+	i := s.index
+	if i < 0 {
+		i += int64(len(a))
+	}
+	if i >= 0 && i < int64(len(a)) {
+		out = append(out, Node{Value: a[i], parent: n, index: int(i)})
+	}
+	return out
+}
+
+// sliceSelector is start:end:step; a nil bound takes the default for the direction of step.
+type sliceSelector struct {
+	start, end *int64
+	step       int64
+}
+
+func (s sliceSelector) apply(n *Node, root parser.Value, out []Node) []Node {
+	a, ok := n.Value.(parser.Array)
+	if !ok || s.step == 0 {
+		return out
+	}
+	length := int64(len(a))
+	bound := func(p *int64, def, lo, hi int64) int64 {
+		i := def
+		if p != nil {
+			i = *p
+			if i < 0 {
+				i += length
+			}
+		}
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	if s.step > 0 {
+		lower := bound(s.start, 0, 0, length)
+		upper := bound(s.end, length, 0, length)
+		for i := lower; i < upper; i += s.step {
+			out = append(out, Node{Value: a[i], parent: n, index: int(i)})
+		}
+		return out
+	}
+	upper := bound(s.start, length-1, -1, length-1)
+	lower := bound(s.end, -length-1, -1, length-1)
+	for i := upper; lower < i; i += s.step {
+		out = append(out, Node{Value: a[i], parent: n, index: int(i)})
+	}
+	return out
+}
+
+// filterSelector keeps the children for which the expression is true.
+type filterSelector struct {
+	expr expr
+}
+
+func (s filterSelector) apply(n *Node, root parser.Value, out []Node) []Node {
+	nodes := children(n)
+	for i := range nodes {
+		c := &evalContext{root: root, current: nodes[i].Value}
+		if s.expr.eval(c).logical {
+			out = append(out, nodes[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package jsonpath
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/toy-json/lexer"
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+func TestPath(t *testing.T) {
+	doc, err := parser.Parse(strings.NewReader(`{"users": [{"name": "a", "age": 30}, {"name": "b", "age": 17}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := MustCompile("$.users[?@.age >= 18].name")
+	if p.String() != "$.users[?@.age >= 18].name" {
+		t.Errorf("String wrong. got=%q", p.String())
+	}
+	// a compiled path can be run any number of times
+	for i := 0; i < 2; i++ {
+		if got := p.Values(doc); !reflect.DeepEqual(got, []parser.Value{parser.String("a")}) {
+			t.Errorf("Values wrong. got=%v", got)
+		}
+	}
+
+	nodes, err := Query("$..age", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var locations []string
+	for _, n := range nodes {
+		locations = append(locations, n.Location())
+	}
+	expected := []string{"$['users'][0]['age']", "$['users'][1]['age']"}
+	if !reflect.DeepEqual(locations, expected) {
+		t.Errorf("locations wrong. got=%q, want=%q", locations, expected)
+	}
+}
+
+func TestRegexpPatterns(t *testing.T) {
+	doc, err := parser.Parse(strings.NewReader(`[{"s": "abc", "p": "a.c"}, {"s": "abd", "p": "b"}, {"s": "x", "p": "["}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a literal pattern is compiled along with the query
+	p := MustCompile(`$[?match(@.s, "a.c")].s`)
+	if _, ok := p.query.segments[0].selectors[0].(filterSelector).expr.(literalRegexp); !ok {
+		t.Errorf("literal pattern was not compiled with the query")
+	}
+	if got := p.Values(doc); !reflect.DeepEqual(got, []parser.Value{parser.String("abc")}) {
+		t.Errorf("literal pattern result wrong. got=%v", got)
+	}
+
+	// patterns from the document are compiled as they are met; invalid ones match nothing
+	if got := MustCompile(`$[?search(@.s, @.p)].s`).Values(doc); !reflect.DeepEqual(got, []parser.Value{parser.String("abc"), parser.String("abd")}) {
+		t.Errorf("document pattern result wrong. got=%v", got)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "1:1: unexpected end of query, expected '$'"},
+		{"$.", "1:3: unexpected end of query, expected member name or '*'"},
+		{"$['a' 'b']", "1:7: unexpected '\\'', expected ',' or ']'"},
+		{"$[01]", "1:3: invalid integer 01"},
+		{"$[9007199254740992]", "1:3: integer 9007199254740992 out of range"},
+		{`$["\q"]`, `1:4: invalid escape sequence \q`},
+		{"$[?@.a]x", "1:8: unexpected 'x', expected segment"},
+		{"$[?1]", "1:4: literal must be compared"},
+		{"$[?length(@)]", "1:4: function result must be compared"},
+		{"$[?@.* == 1]", "1:4: non-singular query is not comparable"},
+		{"$[?count(@.*, 1) == 1]", "1:15: too many arguments to count()"},
+		{"$[?size(@) == 1]", "1:4: unknown function size()"},
+		{"$[\n?@.a ==\n]", "3:1: unexpected ']', expected literal, query or function"},
+	}
+
+	for _, tt := range tests {
+		_, err := Compile(tt.input)
+		var syntaxErr *lexer.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			t.Errorf("%q: expected *lexer.SyntaxError. got=%v", tt.input, err)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile should panic on an invalid query")
+		}
+	}()
+	MustCompile("$[")
+}
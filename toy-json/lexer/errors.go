@@ -0,0 +1,69 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Position is a location in the input. Line and Column start at 1, and Column counts runes.
+type Position struct {
+	Offset int // byte offset
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// SyntaxError is malformed JSON found at Pos.
+type SyntaxError struct {
+	Msg string
+	Pos Position
+}
+
+// NewSyntaxError returns a SyntaxError at pos with a message formatted by fmt.Sprintf.
+func NewSyntaxError(pos Position, format string, args ...interface{}) *SyntaxError {
+	return &SyntaxError{Msg: fmt.Sprintf(format, args...), Pos: pos}
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+}
+
+// Snippet renders the line of input holding the error with a caret under its column:
+//
+//	{"a": 1 "b": 2}
+//	        ^
+//
+// Tabs before the column are kept so the caret lines up.
+func (e *SyntaxError) Snippet(input []byte) string {
+	start := e.Pos.Offset
+	if start > len(input) {
+		start = len(input)
+	}
+	for start > 0 && input[start-1] != '\n' {
+		start--
+	}
+	end := start
+	for end < len(input) && input[end] != '\n' {
+		end++
+	}
+	line := strings.TrimSuffix(string(input[start:end]), "\r")
+
+	var caret strings.Builder
+	col := 1
+	for _, r := range line {
+		if col >= e.Pos.Column {
+			break
+		}
+		if r == '\t' {
+			caret.WriteByte('\t')
+		} else {
+			caret.WriteByte(' ')
+		}
+		col++
+	}
+	caret.WriteByte('^')
+	return line + "\n" + caret.String()
+}
@@ -1,13 +1,15 @@
 package lexer
 
 import (
-	"fmt"
+	"io"
 	"strconv"
-	"strings"
+	"unicode"
+	"unicode/utf16"
 )
 
 type Lexer struct {
 	scanner *Scanner
+	start   Position // position of the token being lexed
 }
 
 func NewLexer(scanner *Scanner) *Lexer {
@@ -18,14 +20,38 @@ func NewLexerWithString(s string) *Lexer {
 	return &Lexer{scanner: NewScannerString(s)}
 }
 
+// GetNextToken returns the next token, or io.EOF at the end of the input.
 func (l *Lexer) GetNextToken() (*Token, error) {
-	r, err := l.scanner.consume()
+	for {
+		r, err := l.scanner.peek()
+		if err != nil {
+			return nil, err
+		}
+		if r != ' ' && r != '\r' && r != '\t' && r != '\n' {
+			break
+		}
+		_, _ = l.scanner.consume()
+	}
+
+	l.start = l.scanner.pos
+	tok, err := l.lexToken()
 	if err != nil {
 		return nil, err
 	}
+	tok.pos = l.start
+	tok.end = l.scanner.pos.Offset
+	return tok, nil
+}
+
+// Pos is the position of the next rune, or of the end of input once it is reached.
+func (l *Lexer) Pos() Position {
+	return l.scanner.pos
+}
 
-	if r == ' ' || r == '\r' || r == '\t' || r == '\n' {
-		return l.GetNextToken()
+func (l *Lexer) lexToken() (*Token, error) {
+	r, err := l.scanner.consume()
+	if err != nil {
+		return nil, err
 	}
 
 	switch r {
@@ -46,46 +72,209 @@ func (l *Lexer) GetNextToken() (*Token, error) {
 	case '"':
 		return l.lexString()
 	case 't':
-		return l.lextToken("true", TokenTrue)
+		return l.lexLiteral("true", TokenTrue)
 	case 'f':
-		return l.lextToken("false", TokenTrue)
+		return l.lexLiteral("false", TokenFalse)
 	case 'n':
-		return l.lextToken("null", TokenTrue)
+		return l.lexLiteral("null", TokenNull)
 	}
 
-	return nil, fmt.Errorf("Unexpected character:%c", r)
+	return nil, NewSyntaxError(l.start, "unexpected character %q", r)
+}
 
+// lexLiteral reads the rest of true, false or null after its first letter.
+func (l *Lexer) lexLiteral(word string, tokenType TokenType) (*Token, error) {
+	for _, want := range word[1:] {
+		r, err := l.scanner.peek()
+		if err != nil || r != want {
+			return nil, NewSyntaxError(l.start, "invalid literal: expected %s", word)
+		}
+		_, _ = l.scanner.consume()
+	}
+	return NewToken(tokenType), nil
 }
 
+// lexNumber reads a number with the grammar of RFC 8259.
+//
+//	number = [ "-" ] int [ frac ] [ exp ]
+//	int    = "0" / ( digit1-9 *DIGIT )
+//	frac   = "." 1*DIGIT
+//	exp    = ( "e" / "E" ) [ "-" / "+" ] 1*DIGIT
 func (l *Lexer) lexNumber(first rune) (*Token, error) {
 	rs := []rune{first}
-	numerics := "0123456789-+e."
 
-	for {
+	if first == '-' {
 		r, err := l.scanner.peek()
-		if err != nil {
-			break
+		if err != nil || r < '0' || '9' < r {
+			return nil, NewSyntaxError(l.start, "invalid number: expected digit after '-'")
 		}
-		if !strings.Contains(numerics, string(r)) {
-			break
+		_, _ = l.scanner.consume()
+		rs = append(rs, r)
+		first = r
+	}
+	if first == '0' {
+		if r, err := l.scanner.peek(); err == nil && '0' <= r && r <= '9' {
+			return nil, NewSyntaxError(l.start, "invalid number: leading zero")
 		}
+	} else {
+		rs = l.lexDigits(rs)
+	}
 
+	if r, err := l.scanner.peek(); err == nil && r == '.' {
 		_, _ = l.scanner.consume()
 		rs = append(rs, r)
+		n := len(rs)
+		if rs = l.lexDigits(rs); len(rs) == n {
+			return nil, NewSyntaxError(l.start, "invalid number: expected digit after '.'")
+		}
+	}
+
+	if r, err := l.scanner.peek(); err == nil && (r == 'e' || r == 'E') {
+		_, _ = l.scanner.consume()
+		rs = append(rs, r)
+		if r, err := l.scanner.peek(); err == nil && (r == '+' || r == '-') {
+			_, _ = l.scanner.consume()
+			rs = append(rs, r)
+		}
+		n := len(rs)
+		if rs = l.lexDigits(rs); len(rs) == n {
+			return nil, NewSyntaxError(l.start, "invalid number: expected digit in exponent")
+		}
 	}
 
 	f, err := strconv.ParseFloat(string(rs), 64)
 	if err != nil {
-		return nil, err
+		return nil, NewSyntaxError(l.start, "number %s out of range", string(rs))
+	}
+	tok := NewTokenNumber(f)
+	tok.literal = string(rs)
+	return tok, nil
+}
+
+// lexDigits appends the digits that follow to rs.
+func (l *Lexer) lexDigits(rs []rune) []rune {
+	for {
+		r, err := l.scanner.peek()
+		if err != nil || r < '0' || '9' < r {
+			return rs
+		}
+		_, _ = l.scanner.consume()
+		rs = append(rs, r)
 	}
-	return NewTokenNumber(f), nil
 }
 
+// lexString reads a string after its opening quote and unescapes it.
 func (l *Lexer) lexString() (*Token, error) {
 	rs := []rune{}
-	backslash := false
 
 	for {
+		pos := l.scanner.pos
+		r, err := l.scanner.consume()
+		if err == io.EOF {
+			return nil, NewSyntaxError(l.start, "unterminated string")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case r == '"':
+			return NewTokenString(string(rs)), nil
+		case r == '\\':
+			e, err := l.lexEscape(pos)
+			if err != nil {
+				return nil, err
+			}
+			rs = append(rs, e)
+		case r < 0x20:
+			return nil, NewSyntaxError(pos, "invalid control character %U in string", r)
+		default:
+			rs = append(rs, r)
+		}
+	}
+}
+
+var escapes = map[rune]rune{
+	'"':  '"',
+	'\\': '\\',
+	'/':  '/',
+	'b':  '\b',
+	'f':  '\f',
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+}
+
+// lexEscape reads an escape sequence after its backslash at pos.
+// A \u escape of a high surrogate must be followed by a \u escape of a low surrogate.
+func (l *Lexer) lexEscape(pos Position) (rune, error) {
+	r, err := l.consume(pos)
+	if err != nil {
+		return 0, err
+	}
+	if e, ok := escapes[r]; ok {
+		return e, nil
+	}
+	if r != 'u' {
+		return 0, NewSyntaxError(pos, "invalid escape sequence \\%c", r)
+	}
 
+	r1, err := l.lexHex4(pos)
+	if err != nil {
+		return 0, err
+	}
+	if !utf16.IsSurrogate(r1) {
+		return r1, nil
+	}
+	if r1 >= 0xdc00 {
+		return 0, NewSyntaxError(pos, "unpaired surrogate \\u%04x", r1)
+	}
+
+	second := l.scanner.pos
+	if b, err := l.consume(pos); err != nil || b != '\\' {
+		return 0, NewSyntaxError(pos, "unpaired surrogate \\u%04x", r1)
+	}
+	if u, err := l.consume(pos); err != nil || u != 'u' {
+		return 0, NewSyntaxError(pos, "unpaired surrogate \\u%04x", r1)
+	}
+	r2, err := l.lexHex4(second)
+	if err != nil {
+		return 0, err
+	}
+	r = utf16.DecodeRune(r1, r2)
+	if r == unicode.ReplacementChar {
+		return 0, NewSyntaxError(pos, "unpaired surrogate \\u%04x", r1)
+	}
+	return r, nil
+}
+
+// lexHex4 reads the four hex digits of a \u escape at pos.
+func (l *Lexer) lexHex4(pos Position) (rune, error) {
+	var v rune
+	for i := 0; i < 4; i++ {
+		r, err := l.consume(pos)
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case '0' <= r && r <= '9':
+			v = v<<4 | (r - '0')
+		case 'a' <= r && r <= 'f':
+			v = v<<4 | (r - 'a' + 10)
+		case 'A' <= r && r <= 'F':
+			v = v<<4 | (r - 'A' + 10)
+		default:
+			return 0, NewSyntaxError(pos, "invalid \\u escape")
+		}
+	}
+	return v, nil
+}
+
+// consume reads the next rune of an escape sequence at pos. The input must not end there.
+func (l *Lexer) consume(pos Position) (rune, error) {
+	r, err := l.scanner.consume()
+	if err == io.EOF {
+		return 0, NewSyntaxError(pos, "unterminated escape sequence")
 	}
+	return r, err
 }
@@ -0,0 +1,276 @@
+package lexer
+
+import (
+	"io"
+	"testing"
+)
+
+func TestLexString(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`""`, ""},
+		{`"hello"`, "hello"},
+		{`"日本語"`, "日本語"},
+		{`"\" \\ \/ \b \f \n \r \t"`, "\" \\ / \b \f \n \r \t"},
+		{`"\u0041\u00e9\u3042"`, "Aéあ"},
+		{`"\u00E9"`, "é"},
+		{`"\ud83d\ude00!"`, "😀!"},
+		{`"\uD834\uDD1E"`, "𝄞"},
+		{`"\u0000"`, "\x00"},
+	}
+
+	for _, tt := range tests {
+		tok, err := NewLexerWithString(tt.input).GetNextToken()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if tok.Type() != TokenString {
+			t.Errorf("%s: token type wrong. got=%d", tt.input, tok.Type())
+		}
+		if tok.String() != tt.expected {
+			t.Errorf("%s: string wrong. got=%q, want=%q", tt.input, tok.String(), tt.expected)
+		}
+	}
+}
+
+func TestLexStringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"abc`, "1:1: unterminated string"},
+		{` ["abc`, "1:3: unterminated string"},
+		{`"abc\`, "1:5: unterminated escape sequence"},
+		{"\"a\nb\"", "1:3: invalid control character U+000A in string"},
+		{"\"\t\"", "1:2: invalid control character U+0009 in string"},
+		{`"\x"`, `1:2: invalid escape sequence \x`},
+		{`"\u12"`, `1:2: invalid \u escape`},
+		{`"\u12g4"`, `1:2: invalid \u escape`},
+		{`"\ude00"`, `1:2: unpaired surrogate \ude00`},
+		{`"\ud83d"`, `1:2: unpaired surrogate \ud83d`},
+		{`"\ud83dx"`, `1:2: unpaired surrogate \ud83d`},
+		{`"\ud83d\n"`, `1:2: unpaired surrogate \ud83d`},
+		{`"\ud83dA"`, `1:2: unpaired surrogate \ud83d`},
+		{`"\ud83d\u00"`, `1:8: invalid \u escape`},
+	}
+
+	for _, tt := range tests {
+		l := NewLexerWithString(tt.input)
+		var err error
+		for err == nil {
+			_, err = l.GetNextToken()
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestLexNumber(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"0", 0},
+		{"-0", 0},
+		{"7", 7},
+		{"-12", -12},
+		{"1234567890", 1234567890},
+		{"0.5", 0.5},
+		{"-3.25", -3.25},
+		{"1e3", 1000},
+		{"1E3", 1000},
+		{"2.5e-2", 0.025},
+		{"2.5E+2", 250},
+		{"0e0", 0},
+		{"1.7976931348623157e308", 1.7976931348623157e308},
+	}
+
+	for _, tt := range tests {
+		tok, err := NewLexerWithString(tt.input).GetNextToken()
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if tok.Type() != TokenNumber {
+			t.Errorf("%s: token type wrong. got=%d", tt.input, tok.Type())
+		}
+		if tok.Number() != tt.expected {
+			t.Errorf("%s: number wrong. got=%g, want=%g", tt.input, tok.Number(), tt.expected)
+		}
+		if tok.Literal() != tt.input {
+			t.Errorf("%s: literal wrong. got=%q", tt.input, tok.Literal())
+		}
+	}
+}
+
+func TestLexNumberErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-", "1:1: invalid number: expected digit after '-'"},
+		{"-a", "1:1: invalid number: expected digit after '-'"},
+		{"01", "1:1: invalid number: leading zero"},
+		{"[-007]", "1:2: invalid number: leading zero"},
+		{"1.", "1:1: invalid number: expected digit after '.'"},
+		{"1.e5", "1:1: invalid number: expected digit after '.'"},
+		{"1e", "1:1: invalid number: expected digit in exponent"},
+		{"1e+", "1:1: invalid number: expected digit in exponent"},
+		{"1e400", "1:1: number 1e400 out of range"},
+		{"+1", "1:1: unexpected character '+'"},
+		{".5", "1:1: unexpected character '.'"},
+	}
+
+	for _, tt := range tests {
+		l := NewLexerWithString(tt.input)
+		var err error
+		for err == nil {
+			_, err = l.GetNextToken()
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestGetNextToken(t *testing.T) {
+	input := ` {"a": [1, -2.5e1, true, false, null],
+	"b" : {}}`
+	expected := []*Token{
+		NewToken(TokenLeftBrace),
+		NewTokenString("a"),
+		NewToken(TokenColon),
+		NewToken(TokenLeftBracket),
+		NewTokenNumber(1),
+		NewToken(TokenComma),
+		NewTokenNumber(-25),
+		NewToken(TokenComma),
+		NewToken(TokenTrue),
+		NewToken(TokenComma),
+		NewToken(TokenFalse),
+		NewToken(TokenComma),
+		NewToken(TokenNull),
+		NewToken(TokenRightBracket),
+		NewToken(TokenComma),
+		NewTokenString("b"),
+		NewToken(TokenColon),
+		NewToken(TokenLeftBrace),
+		NewToken(TokenRightBrace),
+		NewToken(TokenRightBrace),
+	}
+
+	l := NewLexerWithString(input)
+	for i, want := range expected {
+		tok, err := l.GetNextToken()
+		if err != nil {
+			t.Fatalf("tokens[%d]: unexpected error: %v", i, err)
+		}
+		if tok.Type() != want.Type() || tok.String() != want.String() || tok.Number() != want.Number() {
+			t.Errorf("tokens[%d] wrong. got=%s %q %g, want=%s %q %g",
+				i, tok.Type(), tok.String(), tok.Number(), want.Type(), want.String(), want.Number())
+		}
+	}
+	if _, err := l.GetNextToken(); err != io.EOF {
+		t.Errorf("expected io.EOF at the end. got=%v", err)
+	}
+}
+
+func TestLexLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"tru", "1:1: invalid literal: expected true"},
+		{"[fals]", "1:2: invalid literal: expected false"},
+		{"nul", "1:1: invalid literal: expected null"},
+		{"nill", "1:1: invalid literal: expected null"},
+		{"True", "1:1: unexpected character 'T'"},
+	}
+
+	for _, tt := range tests {
+		l := NewLexerWithString(tt.input)
+		var err error
+		for err == nil {
+			_, err = l.GetNextToken()
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestTokenOffsets(t *testing.T) {
+	input := " [\"a\\n\", -1.5,\n\ttrue ]"
+	expected := [][2]int{{1, 2}, {2, 7}, {7, 8}, {9, 13}, {13, 14}, {16, 20}, {21, 22}}
+
+	l := NewLexerWithString(input)
+	for i, want := range expected {
+		tok, err := l.GetNextToken()
+		if err != nil {
+			t.Fatalf("tokens[%d]: unexpected error: %v", i, err)
+		}
+		if got := [2]int{tok.Offset(), tok.End()}; got != want {
+			t.Errorf("tokens[%d] %s offsets wrong. got=%v, want=%v", i, tok.Type(), got, want)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "{\n\t\"日本\": [1,\r\n  true]}"
+	expected := []Position{
+		{0, 1, 1}, {3, 2, 2}, {11, 2, 6}, {13, 2, 8}, {14, 2, 9}, {15, 2, 10}, {20, 3, 3}, {24, 3, 7}, {25, 3, 8},
+	}
+
+	l := NewLexerWithString(input)
+	for i, want := range expected {
+		tok, err := l.GetNextToken()
+		if err != nil {
+			t.Fatalf("tokens[%d]: unexpected error: %v", i, err)
+		}
+		if tok.Pos() != want {
+			t.Errorf("tokens[%d] %s position wrong. got=%+v, want=%+v", i, tok.Type(), tok.Pos(), want)
+		}
+	}
+	if _, err := l.GetNextToken(); err != io.EOF {
+		t.Errorf("expected io.EOF at the end. got=%v", err)
+	}
+	if want := (Position{26, 3, 9}); l.Pos() != want {
+		t.Errorf("end position wrong. got=%+v, want=%+v", l.Pos(), want)
+	}
+}
+
+func TestSyntaxError(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		snippet  string
+	}{
+		{"[1,\n\t\"a\", x]", "2:7: unexpected character 'x'", "\t\"a\", x]\n\t     ^"},
+		{"[\"\xff\"]", "1:3: invalid UTF-8 encoding", "[\"\xff\"]\n  ^"},
+		{"\"日本\\q\"", "1:4: invalid escape sequence \\q", "\"日本\\q\"\n   ^"},
+		{"[\r\n01]", "2:1: invalid number: leading zero", "01]\n^"},
+	}
+
+	for _, tt := range tests {
+		l := NewLexerWithString(tt.input)
+		var err error
+		for err == nil {
+			_, err = l.GetNextToken()
+		}
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("%q: expected *SyntaxError. got=%T %v", tt.input, err, err)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+		if got := syntaxErr.Snippet([]byte(tt.input)); got != tt.snippet {
+			t.Errorf("%q: snippet wrong. got=%q, want=%q", tt.input, got, tt.snippet)
+		}
+	}
+}
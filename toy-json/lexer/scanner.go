@@ -2,7 +2,6 @@ package lexer
 
 import (
 	"bufio"
-	"fmt"
 	"io"
 	"strings"
 	"unicode"
@@ -13,10 +12,12 @@ type Scanner struct {
 	reader  *bufio.Reader
 	current rune
 	error   error
+	pos     Position // position of current
+	next    Position // position of the rune after current
 }
 
 func NewScanner(reader io.Reader) *Scanner {
-	s := &Scanner{reader: bufio.NewReader(reader)}
+	s := &Scanner{reader: bufio.NewReader(reader), next: Position{Line: 1, Column: 1}}
 	s.read()
 	return s
 }
@@ -27,12 +28,20 @@ func NewScannerString(s string) *Scanner {
 
 func (s *Scanner) read() {
 	r, size, err := s.reader.ReadRune()
-	if s.current == unicode.ReplacementChar && size == 1 {
-		err = fmt.Errorf("invalid unicode")
+	s.pos = s.next
+	if err == nil && r == unicode.ReplacementChar && size == 1 {
+		err = NewSyntaxError(s.pos, "invalid UTF-8 encoding")
 	}
 
 	s.current = r
 	s.error = err
+	s.next.Offset += size
+	if r == '\n' {
+		s.next.Line++
+		s.next.Column = 1
+	} else if size > 0 {
+		s.next.Column++
+	}
 }
 
 func (s *Scanner) peek() (rune, error) {
@@ -49,9 +58,3 @@ func (s *Scanner) consume() (rune, error) {
 
 	return c, nil
 }
-
-type EotError struct{}
-
-func (e EotError) Error() string {
-	return fmt.Sprintf("end of string")
-}
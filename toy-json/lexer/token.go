@@ -21,6 +21,9 @@ type Token struct {
 	tokenType   TokenType
 	stringValue string
 	numberValue float64
+	literal     string   // source text of a number
+	pos         Position // position of the first character
+	end         int      // byte offset just after the last character
 }
 
 func NewToken(tokenType TokenType) *Token {
@@ -31,7 +34,7 @@ func NewTokenString(value string) *Token {
 	return &Token{tokenType: TokenString, stringValue: value}
 }
 func NewTokenNumber(value float64) *Token {
-	return &Token{tokenType: TokenString, numberValue: value}
+	return &Token{tokenType: TokenNumber, numberValue: value}
 }
 
 func (t Token) Type() TokenType {
@@ -45,3 +48,44 @@ func (t Token) Number() float64 {
 func (t Token) String() string {
 	return t.stringValue
 }
+
+// Literal is the number as written in the input, e.g. "1.50e+2".
+func (t Token) Literal() string {
+	return t.literal
+}
+
+// Pos is the position of the token in the input.
+func (t Token) Pos() Position {
+	return t.pos
+}
+
+// Offset is the byte offset of the token in the input.
+func (t Token) Offset() int {
+	return t.pos.Offset
+}
+
+// End is the byte offset just after the token.
+func (t Token) End() int {
+	return t.end
+}
+
+var tokenNames = map[TokenType]string{
+	TokenLeftBracket:  "'['",
+	TokenRightBracket: "']'",
+	TokenLeftBrace:    "'{'",
+	TokenRightBrace:   "'}'",
+	TokenColon:        "':'",
+	TokenComma:        "','",
+	TokenNull:         "null",
+	TokenTrue:         "true",
+	TokenFalse:        "false",
+	TokenNumber:       "number",
+	TokenString:       "string",
+}
+
+func (t TokenType) String() string {
+	if name, ok := tokenNames[t]; ok {
+		return name
+	}
+	return "unknown token"
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"io"
+
+	"github.com/tMinamiii/various-parser/toy-json/lexer"
+)
+
+// DuplicateKeyPolicy decides what happens when an object has the same key twice.
+type DuplicateKeyPolicy int8
+
+const (
+	// DuplicateKeyError rejects the document.
+	DuplicateKeyError DuplicateKeyPolicy = iota
+	// DuplicateKeyFirst keeps the first value.
+	DuplicateKeyFirst
+	// DuplicateKeyLast keeps the last value at the position of the first key.
+	DuplicateKeyLast
+)
+
+// MaxDepth is how deeply arrays and objects may nest.
+// Deeper input is a syntax error rather than a stack overflow.
+const MaxDepth = 10000
+
+// Options relax the strict RFC 8259 parsing done by Parse.
+type Options struct {
+	AllowTrailingCommas bool
+	DuplicateKeys       DuplicateKeyPolicy
+}
+
+// Parse reads exactly one JSON value from r.
+// Trailing commas and duplicate keys are errors. Malformed input gives a *lexer.SyntaxError.
+func Parse(r io.Reader) (Value, error) {
+	return ParseWithOptions(r, Options{})
+}
+
+// ParseWithOptions is Parse with the trailing comma and duplicate key policies in opts.
+func ParseWithOptions(r io.Reader, opts Options) (Value, error) {
+	p := &parser{lexer: lexer.NewLexer(lexer.NewScanner(r)), opts: opts}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	v, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != nil {
+		return nil, lexer.NewSyntaxError(p.tok.Pos(), "unexpected %s after top-level value", p.tok.Type())
+	}
+	return v, nil
+}
+
+type parser struct {
+	lexer *lexer.Lexer
+	opts  Options
+	tok   *lexer.Token // nil at the end of input
+	depth int          // arrays and objects currently open
+}
+
+func (p *parser) next() error {
+	tok, err := p.lexer.GetNextToken()
+	if err == io.EOF {
+		p.tok = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) is(t lexer.TokenType) bool {
+	return p.tok != nil && p.tok.Type() == t
+}
+
+func (p *parser) unexpected(expected string) error {
+	if p.tok == nil {
+		return lexer.NewSyntaxError(p.lexer.Pos(), "unexpected end of input, expected %s", expected)
+	}
+	return lexer.NewSyntaxError(p.tok.Pos(), "unexpected %s, expected %s", p.tok.Type(), expected)
+}
+
+func (p *parser) parseValue() (Value, error) {
+	if p.tok == nil {
+		return nil, p.unexpected("value")
+	}
+
+	var v Value
+	switch p.tok.Type() {
+	case lexer.TokenLeftBrace, lexer.TokenLeftBracket:
+		if p.depth == MaxDepth {
+			return nil, lexer.NewSyntaxError(p.tok.Pos(), "exceeded max depth of %d", MaxDepth)
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.tok.Type() == lexer.TokenLeftBrace {
+			return p.parseObject()
+		}
+		return p.parseArray()
+	case lexer.TokenNull:
+		v = Null{}
+	case lexer.TokenTrue:
+		v = Bool(true)
+	case lexer.TokenFalse:
+		v = Bool(false)
+	case lexer.TokenNumber:
+		v = Number(p.tok.Number())
+	case lexer.TokenString:
+		v = String(p.tok.String())
+	default:
+		return nil, p.unexpected("value")
+	}
+	return v, p.next()
+}
+
+func (p *parser) parseArray() (Value, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	array := Array{}
+	if p.is(lexer.TokenRightBracket) {
+		return array, p.next()
+	}
+
+	for {
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, v)
+
+		switch {
+		case p.is(lexer.TokenComma):
+			comma := p.tok.Pos()
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.is(lexer.TokenRightBracket) {
+				if !p.opts.AllowTrailingCommas {
+					return nil, lexer.NewSyntaxError(comma, "trailing comma in array")
+				}
+				return array, p.next()
+			}
+		case p.is(lexer.TokenRightBracket):
+			return array, p.next()
+		default:
+			return nil, p.unexpected("',' or ']'")
+		}
+	}
+}
+
+func (p *parser) parseObject() (Value, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	object := Object{Members: []Member{}}
+	if p.is(lexer.TokenRightBrace) {
+		return object, p.next()
+	}
+
+	index := make(map[string]int) // key -> position in object.Members
+	for {
+		if !p.is(lexer.TokenString) {
+			return nil, p.unexpected("string key")
+		}
+		key, keyPos := p.tok.String(), p.tok.Pos()
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if !p.is(lexer.TokenColon) {
+			return nil, p.unexpected("':'")
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		if i, ok := index[key]; ok {
+			switch p.opts.DuplicateKeys {
+			case DuplicateKeyError:
+				return nil, lexer.NewSyntaxError(keyPos, "duplicate key %q", key)
+			case DuplicateKeyLast:
+				object.Members[i].Value = v
+			}
+		} else {
+			index[key] = len(object.Members)
+			object.Members = append(object.Members, Member{Key: key, Value: v})
+		}
+
+		switch {
+		case p.is(lexer.TokenComma):
+			comma := p.tok.Pos()
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.is(lexer.TokenRightBrace) {
+				if !p.opts.AllowTrailingCommas {
+					return nil, lexer.NewSyntaxError(comma, "trailing comma in object")
+				}
+				return object, p.next()
+			}
+		case p.is(lexer.TokenRightBrace):
+			return object, p.next()
+		default:
+			return nil, p.unexpected("',' or '}'")
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/toy-json/lexer"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Value
+	}{
+		{"null", Null{}},
+		{"true", Bool(true)},
+		{" false ", Bool(false)},
+		{"-1.5e2", Number(-150)},
+		{`"a\nb"`, String("a\nb")},
+		{"[]", Array{}},
+		{"{}", Object{Members: []Member{}}},
+		{"[1, [2, []], {}]", Array{Number(1), Array{Number(2), Array{}}, Object{Members: []Member{}}}},
+		{
+			`{"b": 1, "a": [true, null], "c": {"d": "e"}}`,
+			Object{Members: []Member{
+				{"b", Number(1)},
+				{"a", Array{Bool(true), Null{}}},
+				{"c", Object{Members: []Member{{"d", String("e")}}}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		v, err := Parse(strings.NewReader(tt.input))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("%s: value wrong. got=%#v, want=%#v", tt.input, v, tt.expected)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "1:1: unexpected end of input, expected value"},
+		{"   ", "1:4: unexpected end of input, expected value"},
+		{"[", "1:2: unexpected end of input, expected value"},
+		{"[1", "1:3: unexpected end of input, expected ',' or ']'"},
+		{"[1 2]", "1:4: unexpected number, expected ',' or ']'"},
+		{"[1,]", "1:3: trailing comma in array"},
+		{"[,1]", "1:2: unexpected ',', expected value"},
+		{"]", "1:1: unexpected ']', expected value"},
+		{"{", "1:2: unexpected end of input, expected string key"},
+		{"{1: 2}", "1:2: unexpected number, expected string key"},
+		{`{"a" 1}`, "1:6: unexpected number, expected ':'"},
+		{`{"a": }`, "1:7: unexpected '}', expected value"},
+		{`{"a": 1,}`, "1:8: trailing comma in object"},
+		{`{"a": 1 "b": 2}`, "1:9: unexpected string, expected ',' or '}'"},
+		{`{"a": 1, "a": 2}`, `1:10: duplicate key "a"`},
+		{"1 2", "1:3: unexpected number after top-level value"},
+		{"{} []", "1:4: unexpected '[' after top-level value"},
+		{`"a" x`, "1:5: unexpected character 'x'"},
+		{"[01]", "1:2: invalid number: leading zero"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input))
+		if err == nil {
+			t.Errorf("%q: expected error %q", tt.input, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestParseWithOptions(t *testing.T) {
+	tests := []struct {
+		input    string
+		opts     Options
+		expected Value
+	}{
+		{"[1, 2,]", Options{AllowTrailingCommas: true}, Array{Number(1), Number(2)}},
+		{
+			`{"a": 1, "b": {"c": 2,},}`,
+			Options{AllowTrailingCommas: true},
+			Object{Members: []Member{{"a", Number(1)}, {"b", Object{Members: []Member{{"c", Number(2)}}}}}},
+		},
+		{
+			`{"a": 1, "b": 2, "a": 3}`,
+			Options{DuplicateKeys: DuplicateKeyFirst},
+			Object{Members: []Member{{"a", Number(1)}, {"b", Number(2)}}},
+		},
+		{
+			`{"a": 1, "b": 2, "a": 3}`,
+			Options{DuplicateKeys: DuplicateKeyLast},
+			Object{Members: []Member{{"a", Number(3)}, {"b", Number(2)}}},
+		},
+	}
+
+	for _, tt := range tests {
+		v, err := ParseWithOptions(strings.NewReader(tt.input), tt.opts)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("%s: value wrong. got=%#v, want=%#v", tt.input, v, tt.expected)
+		}
+	}
+
+	if _, err := ParseWithOptions(strings.NewReader("[,]"), Options{AllowTrailingCommas: true}); err == nil {
+		t.Errorf("[,]: expected an error")
+	}
+}
+
+func TestObjectGet(t *testing.T) {
+	v, err := Parse(strings.NewReader(`{"z": 1, "a": "x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := v.(Object)
+	if got, ok := o.Get("a"); !ok || got != String("x") {
+		t.Errorf("Get(a) wrong. got=%#v, %t", got, ok)
+	}
+	if _, ok := o.Get("b"); ok {
+		t.Errorf("Get(b) should not be found")
+	}
+	if keys := o.Keys(); !reflect.DeepEqual(keys, []string{"z", "a"}) {
+		t.Errorf("Keys wrong. got=%q", keys)
+	}
+}
+
+func TestParseMaxDepth(t *testing.T) {
+	input := strings.Repeat("[", MaxDepth) + strings.Repeat("]", MaxDepth)
+	if _, err := Parse(strings.NewReader(input)); err != nil {
+		t.Fatalf("unexpected error at max depth: %v", err)
+	}
+
+	// far deeper than MaxDepth, which would overflow the stack without the limit
+	input = strings.Repeat(`{"a":[`, 500000)
+	_, err := Parse(strings.NewReader(input))
+	var syntaxErr *lexer.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected *lexer.SyntaxError. got=%T %v", err, err)
+	}
+	if expected := "1:30001: exceeded max depth of 10000"; err.Error() != expected {
+		t.Errorf("error wrong. got=%q, want=%q", err.Error(), expected)
+	}
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	input := "{\n  \"a\": 1,\n  \"b\": [true false]\n}"
+	_, err := Parse(strings.NewReader(input))
+	var syntaxErr *lexer.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		t.Fatalf("expected *lexer.SyntaxError. got=%T %v", err, err)
+	}
+	if want := (lexer.Position{Offset: 25, Line: 3, Column: 14}); syntaxErr.Pos != want {
+		t.Errorf("position wrong. got=%+v, want=%+v", syntaxErr.Pos, want)
+	}
+	expected := "  \"b\": [true false]\n             ^"
+	if got := syntaxErr.Snippet([]byte(input)); got != expected {
+		t.Errorf("snippet wrong. got=%q, want=%q", got, expected)
+	}
+}
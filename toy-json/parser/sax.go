@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"errors"
+	"io"
+
+	"github.com/tMinamiii/various-parser/toy-json/lexer"
+)
+
+// Handler receives the structure of a document from ParseWithHandler as it is read.
+// Returning ErrStop from any method ends parsing without an error;
+// any other error ends it and is returned as it is.
+type Handler interface {
+	StartObject() error
+	Key(key string) error
+	EndObject() error
+	StartArray() error
+	EndArray() error
+	String(s string) error
+	Number(n float64) error
+	Bool(b bool) error
+	Null() error
+}
+
+// ErrStop is returned by a Handler to stop parsing early.
+var ErrStop = errors.New("stop parsing")
+
+// ParseWithHandler reads one JSON value from r and reports it to h without building a tree.
+// Nesting is tracked on an explicit stack, so deep documents do not grow the Go stack.
+// Syntax is checked as strictly as Parse, except that duplicate keys are passed to h,
+// since catching them would mean remembering every key of every open object.
+// Events already reported stay reported when a syntax error is found later.
+func ParseWithHandler(r io.Reader, h Handler) error {
+	p := &saxParser{lexer: lexer.NewLexer(lexer.NewScanner(r)), h: h}
+	err := p.parse()
+	if errors.Is(err, ErrStop) {
+		return nil
+	}
+	return err
+}
+
+// what the SAX parser expects next
+type saxState int8
+
+const (
+	saxValue       saxState = iota
+	saxArrayStart           // value or ']'
+	saxArrayValue           // value after ','
+	saxArrayNext            // ',' or ']'
+	saxObjectStart          // key or '}'
+	saxObjectKey            // key after ','
+	saxObjectColon          // ':'
+	saxObjectNext           // ',' or '}'
+	saxDone                 // the top-level value is complete
+)
+
+var saxExpected = map[saxState]string{
+	saxValue:       "value",
+	saxArrayStart:  "value",
+	saxArrayValue:  "value",
+	saxArrayNext:   "',' or ']'",
+	saxObjectStart: "string key",
+	saxObjectKey:   "string key",
+	saxObjectColon: "':'",
+	saxObjectNext:  "',' or '}'",
+}
+
+type saxParser struct {
+	lexer *lexer.Lexer
+	h     Handler
+	stack []lexer.TokenType // the opening '[' or '{' of each open container
+	state saxState
+	comma lexer.Position // the last ',', to report a trailing one
+}
+
+func (p *saxParser) parse() error {
+	for {
+		tok, err := p.lexer.GetNextToken()
+		if err == io.EOF {
+			if p.state == saxDone {
+				return nil
+			}
+			return lexer.NewSyntaxError(p.lexer.Pos(), "unexpected end of input, expected %s", saxExpected[p.state])
+		}
+		if err != nil {
+			return err
+		}
+
+		switch p.state {
+		case saxDone:
+			return lexer.NewSyntaxError(tok.Pos(), "unexpected %s after top-level value", tok.Type())
+
+		case saxArrayStart, saxArrayValue:
+			if tok.Type() != lexer.TokenRightBracket {
+				err = p.value(tok)
+			} else if p.state == saxArrayValue {
+				err = lexer.NewSyntaxError(p.comma, "trailing comma in array")
+			} else {
+				err = p.close(p.h.EndArray)
+			}
+
+		case saxArrayNext:
+			switch tok.Type() {
+			case lexer.TokenComma:
+				p.state = saxArrayValue
+				p.comma = tok.Pos()
+			case lexer.TokenRightBracket:
+				err = p.close(p.h.EndArray)
+			default:
+				err = p.unexpected(tok)
+			}
+
+		case saxObjectStart, saxObjectKey:
+			switch {
+			case tok.Type() == lexer.TokenString:
+				err = p.h.Key(tok.String())
+				p.state = saxObjectColon
+			case tok.Type() == lexer.TokenRightBrace && p.state == saxObjectKey:
+				err = lexer.NewSyntaxError(p.comma, "trailing comma in object")
+			case tok.Type() == lexer.TokenRightBrace:
+				err = p.close(p.h.EndObject)
+			default:
+				err = p.unexpected(tok)
+			}
+
+		case saxObjectColon:
+			if tok.Type() != lexer.TokenColon {
+				err = p.unexpected(tok)
+			}
+			p.state = saxValue
+
+		case saxObjectNext:
+			switch tok.Type() {
+			case lexer.TokenComma:
+				p.state = saxObjectKey
+				p.comma = tok.Pos()
+			case lexer.TokenRightBrace:
+				err = p.close(p.h.EndObject)
+			default:
+				err = p.unexpected(tok)
+			}
+
+		default:
+			err = p.value(tok)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (p *saxParser) unexpected(tok *lexer.Token) error {
+	return lexer.NewSyntaxError(tok.Pos(), "unexpected %s, expected %s", tok.Type(), saxExpected[p.state])
+}
+
+// value handles a token where a value must start.
+func (p *saxParser) value(tok *lexer.Token) error {
+	var err error
+	switch tok.Type() {
+	case lexer.TokenLeftBracket:
+		p.stack = append(p.stack, lexer.TokenLeftBracket)
+		p.state = saxArrayStart
+		return p.h.StartArray()
+	case lexer.TokenLeftBrace:
+		p.stack = append(p.stack, lexer.TokenLeftBrace)
+		p.state = saxObjectStart
+		return p.h.StartObject()
+	case lexer.TokenNull:
+		err = p.h.Null()
+	case lexer.TokenTrue:
+		err = p.h.Bool(true)
+	case lexer.TokenFalse:
+		err = p.h.Bool(false)
+	case lexer.TokenNumber:
+		err = p.h.Number(tok.Number())
+	case lexer.TokenString:
+		err = p.h.String(tok.String())
+	default:
+		return p.unexpected(tok)
+	}
+	p.afterValue()
+	return err
+}
+
+// close pops the innermost container and reports its end.
+func (p *saxParser) close(end func() error) error {
+	p.stack = p.stack[:len(p.stack)-1]
+	p.afterValue()
+	return end()
+}
+
+// afterValue moves on once a value is complete, depending on what encloses it.
+func (p *saxParser) afterValue() {
+	switch {
+	case len(p.stack) == 0:
+		p.state = saxDone
+	case p.stack[len(p.stack)-1] == lexer.TokenLeftBracket:
+		p.state = saxArrayNext
+	default:
+		p.state = saxObjectNext
+	}
+}
@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recorder writes every event as a short string and can stop after a number of events.
+type recorder struct {
+	events  []string
+	stopAt  int
+	failErr error
+}
+
+func (r *recorder) add(event string) error {
+	r.events = append(r.events, event)
+	if r.stopAt > 0 && len(r.events) == r.stopAt {
+		if r.failErr != nil {
+			return r.failErr
+		}
+		return ErrStop
+	}
+	return nil
+}
+
+func (r *recorder) StartObject() error     { return r.add("{") }
+func (r *recorder) Key(key string) error   { return r.add("key:" + key) }
+func (r *recorder) EndObject() error       { return r.add("}") }
+func (r *recorder) StartArray() error      { return r.add("[") }
+func (r *recorder) EndArray() error        { return r.add("]") }
+func (r *recorder) String(s string) error  { return r.add("string:" + s) }
+func (r *recorder) Number(n float64) error { return r.add(fmt.Sprintf("number:%g", n)) }
+func (r *recorder) Bool(b bool) error      { return r.add(fmt.Sprintf("bool:%t", b)) }
+func (r *recorder) Null() error            { return r.add("null") }
+
+func TestParseWithHandler(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"null", []string{"null"}},
+		{" -1.5e2 ", []string{"number:-150"}},
+		{"[]", []string{"[", "]"}},
+		{"{}", []string{"{", "}"}},
+		{
+			`{"a": [1, "x", true, {}], "b": {"c": null}, "a": false}`,
+			[]string{
+				"{", "key:a", "[", "number:1", "string:x", "bool:true", "{", "}", "]",
+				"key:b", "{", "key:c", "null", "}", "key:a", "bool:false", "}",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		r := &recorder{}
+		if err := ParseWithHandler(strings.NewReader(tt.input), r); err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(r.events, tt.expected) {
+			t.Errorf("%s: events wrong. got=%q, want=%q", tt.input, r.events, tt.expected)
+		}
+	}
+}
+
+func TestParseWithHandlerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", "1:1: unexpected end of input, expected value"},
+		{"[", "1:2: unexpected end of input, expected value"},
+		{"[1", "1:3: unexpected end of input, expected ',' or ']'"},
+		{"[1 2]", "1:4: unexpected number, expected ',' or ']'"},
+		{"[1,]", "1:3: trailing comma in array"},
+		{"[,1]", "1:2: unexpected ',', expected value"},
+		{"[}", "1:2: unexpected '}', expected value"},
+		{`{"a": [}`, "1:8: unexpected '}', expected value"},
+		{"{1: 2}", "1:2: unexpected number, expected string key"},
+		{`{"a" 1}`, "1:6: unexpected number, expected ':'"},
+		{`{"a": 1,}`, "1:8: trailing comma in object"},
+		{`{"a": 1]`, "1:8: unexpected ']', expected ',' or '}'"},
+		{"{} []", "1:4: unexpected '[' after top-level value"},
+		{"[01]", "1:2: invalid number: leading zero"},
+	}
+
+	for _, tt := range tests {
+		err := ParseWithHandler(strings.NewReader(tt.input), &recorder{})
+		if err == nil {
+			t.Errorf("%q: expected error %q", tt.input, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestParseWithHandlerStop(t *testing.T) {
+	// stopping at the third event never reaches the syntax error after it
+	r := &recorder{stopAt: 3}
+	if err := ParseWithHandler(strings.NewReader(`[1, 2, 3, oops`), r); err != nil {
+		t.Errorf("ErrStop should end parsing without an error. got=%v", err)
+	}
+	if expected := []string{"[", "number:1", "number:2"}; !reflect.DeepEqual(r.events, expected) {
+		t.Errorf("events wrong. got=%q, want=%q", r.events, expected)
+	}
+
+	failure := errors.New("handler failed")
+	r = &recorder{stopAt: 2, failErr: failure}
+	if err := ParseWithHandler(strings.NewReader(`{"a": 1}`), r); err != failure {
+		t.Errorf("handler errors should be returned as they are. got=%v", err)
+	}
+}
+
+func TestParseWithHandlerDeep(t *testing.T) {
+	depth := 100000
+	input := strings.Repeat("[", depth) + strings.Repeat("]", depth)
+	r := &recorder{}
+	if err := ParseWithHandler(strings.NewReader(input), r); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.events) != 2*depth {
+		t.Errorf("event count wrong. got=%d, want=%d", len(r.events), 2*depth)
+	}
+}
@@ -0,0 +1,84 @@
+package parser
+
+// Value is a parsed JSON value: Null, Bool, Number, String, Array or Object.
+type Value interface {
+	value()
+}
+
+type Null struct{}
+
+type Bool bool
+
+type Number float64
+
+type String string
+
+type Array []Value
+
+// Object keeps its members in the order they appear in the document.
+type Object struct {
+	Members []Member
+}
+
+// Member is a key and its value in an Object.
+type Member struct {
+	Key   string
+	Value Value
+}
+
+func (Null) value()   {}
+func (Bool) value()   {}
+func (Number) value() {}
+func (String) value() {}
+func (Array) value()  {}
+func (Object) value() {}
+
+// Get returns the value of key, or false if the object has no such member.
+func (o Object) Get(key string) (Value, bool) {
+	for _, m := range o.Members {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Keys returns the keys in document order.
+func (o Object) Keys() []string {
+	keys := make([]string, len(o.Members))
+	for i, m := range o.Members {
+		keys[i] = m.Key
+	}
+	return keys
+}
+
+// Equal reports whether a and b are the same JSON value.
+// Object members are compared regardless of their order.
+func Equal(a, b Value) bool {
+	switch x := a.(type) {
+	case Object:
+		y, ok := b.(Object)
+		if !ok || len(x.Members) != len(y.Members) {
+			return false
+		}
+		for _, m := range x.Members {
+			v, ok := y.Get(m.Key)
+			if !ok || !Equal(m.Value, v) {
+				return false
+			}
+		}
+		return true
+	case Array:
+		y, ok := b.(Array)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !Equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
@@ -0,0 +1,305 @@
+// Package patch implements JSON Patch (RFC 6902) over parser.Value trees.
+package patch
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+	"github.com/tMinamiii/various-parser/toy-json/pointer"
+)
+
+// Operation is one step of a Patch. From is used by move and copy,
+// Value by add, replace and test.
+type Operation struct {
+	Op    string       `json:"op"`
+	Path  string       `json:"path"`
+	From  string       `json:"from,omitempty"`
+	Value parser.Value `json:"value,omitempty"`
+}
+
+// Patch is a list of operations applied in order.
+type Patch []Operation
+
+// Error reports the operation that made Apply fail.
+type Error struct {
+	Index int // position of the operation in the patch
+	Op    Operation
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("patch operation %d (%s %s): %v", e.Index, e.Op.Op, e.Op.Path, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Parse reads a patch document: an array of operation objects.
+func Parse(r io.Reader) (Patch, error) {
+	doc, err := parser.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	array, ok := doc.(parser.Array)
+	if !ok {
+		return nil, fmt.Errorf("patch must be an array of operations")
+	}
+
+	p := make(Patch, len(array))
+	for i, v := range array {
+		o, ok := v.(parser.Object)
+		if !ok {
+			return nil, fmt.Errorf("operation %d is not an object", i)
+		}
+		op, err := stringMember(o, "op")
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		if p[i].Path, err = stringMember(o, "path"); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+		p[i].Op = op
+
+		switch op {
+		case "add", "replace", "test":
+			if p[i].Value, ok = o.Get("value"); !ok {
+				return nil, fmt.Errorf("operation %d: %s needs a value", i, op)
+			}
+		case "move", "copy":
+			if p[i].From, err = stringMember(o, "from"); err != nil {
+				return nil, fmt.Errorf("operation %d: %w", i, err)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("operation %d: unknown op %q", i, op)
+		}
+	}
+	return p, nil
+}
+
+func stringMember(o parser.Object, key string) (string, error) {
+	v, ok := o.Get(key)
+	if !ok {
+		return "", fmt.Errorf("missing %q", key)
+	}
+	s, ok := v.(parser.String)
+	if !ok {
+		return "", fmt.Errorf("%q must be a string", key)
+	}
+	return string(s), nil
+}
+
+// Apply returns doc with the patch applied. doc itself is never modified:
+// every operation rebuilds only the path it changes and shares the rest,
+// so when an operation fails the error is returned and doc is as it was.
+func Apply(doc parser.Value, p Patch) (parser.Value, error) {
+	for i, op := range p {
+		var err error
+		if doc, err = apply(doc, op); err != nil {
+			return nil, &Error{Index: i, Op: op, Err: err}
+		}
+	}
+	return doc, nil
+}
+
+func apply(doc parser.Value, op Operation) (parser.Value, error) {
+	path, err := pointer.Parse(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return add(doc, path, op.Value)
+	case "remove":
+		return remove(doc, path)
+	case "replace":
+		if len(path) == 0 {
+			return op.Value, nil
+		}
+		return update(doc, path, func(parent parser.Value, tok string) (parser.Value, error) {
+			return replaceIn(parent, tok, op.Value)
+		})
+	case "move", "copy":
+		from, err := pointer.Parse(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := from.Get(doc)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if path.HasPrefix(from) && len(path) > len(from) {
+				return nil, fmt.Errorf("cannot move %s into itself", from)
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		}
+		return add(doc, path, v)
+	case "test":
+		v, err := path.Get(doc)
+		if err != nil {
+			return nil, err
+		}
+		if !parser.Equal(v, op.Value) {
+			return nil, fmt.Errorf("test failed: value differs")
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op.Op)
+}
+
+func add(doc parser.Value, path pointer.Pointer, v parser.Value) (parser.Value, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return update(doc, path, func(parent parser.Value, tok string) (parser.Value, error) {
+		switch c := parent.(type) {
+		case parser.Object:
+			if _, ok := c.Get(tok); ok {
+				return replaceIn(c, tok, v)
+			}
+			members := append(append([]parser.Member{}, c.Members...), parser.Member{Key: tok, Value: v})
+			return parser.Object{Members: members}, nil
+		case parser.Array:
+			i := len(c)
+			if tok != "-" {
+				var err error
+				if i, err = pointer.Index(tok, len(c)+1); err != nil {
+					return nil, err
+				}
+			}
+			array := make(parser.Array, 0, len(c)+1)
+			array = append(append(append(array, c[:i]...), v), c[i:]...)
+			return array, nil
+		}
+		return nil, fmt.Errorf("cannot add to a scalar")
+	})
+}
+
+func remove(doc parser.Value, path pointer.Pointer) (parser.Value, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+	return update(doc, path, func(parent parser.Value, tok string) (parser.Value, error) {
+		switch c := parent.(type) {
+		case parser.Object:
+			for i, m := range c.Members {
+				if m.Key == tok {
+					members := append(append([]parser.Member{}, c.Members[:i]...), c.Members[i+1:]...)
+					return parser.Object{Members: members}, nil
+				}
+			}
+			return nil, fmt.Errorf("member %q not found", tok)
+		case parser.Array:
+			i, err := pointer.Index(tok, len(c))
+			if err != nil {
+				return nil, err
+			}
+			return append(append(parser.Array{}, c[:i]...), c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove from a scalar")
+	})
+}
+
+// replaceIn returns a copy of the object or array with the existing member tok set to v.
+func replaceIn(parent parser.Value, tok string, v parser.Value) (parser.Value, error) {
+	switch c := parent.(type) {
+	case parser.Object:
+		for i, m := range c.Members {
+			if m.Key == tok {
+				members := append([]parser.Member{}, c.Members...)
+				members[i].Value = v
+				return parser.Object{Members: members}, nil
+			}
+		}
+		return nil, fmt.Errorf("member %q not found", tok)
+	case parser.Array:
+		i, err := pointer.Index(tok, len(c))
+		if err != nil {
+			return nil, err
+		}
+		array := append(parser.Array{}, c...)
+		array[i] = v
+		return array, nil
+	}
+	return nil, fmt.Errorf("cannot index into a scalar")
+}
+
+// update rebuilds doc along path, letting f replace the container that holds the target.
+// Values off the path are shared with doc, never modified.
+func update(doc parser.Value, path pointer.Pointer, f func(parent parser.Value, tok string) (parser.Value, error)) (parser.Value, error) {
+	if len(path) == 1 {
+		return f(doc, path[0])
+	}
+	child, err := path[:1].Get(doc)
+	if err != nil {
+		return nil, err
+	}
+	child, err = update(child, path[1:], f)
+	if err != nil {
+		return nil, err
+	}
+	return replaceIn(doc, path[0], child)
+}
+
+// Equal reports whether a and b are the same JSON value.
+// Object members are compared regardless of their order.
+// It is parser.Equal, kept here for existing callers.
+func Equal(a, b parser.Value) bool {
+	return parser.Equal(a, b)
+}
+
+// Diff returns a patch that turns a into b.
+// Objects are compared member by member, and arrays index by index,
+// with extra elements added or removed at the end.
+func Diff(a, b parser.Value) Patch {
+	return diff(pointer.Pointer{}, a, b, nil)
+}
+
+func diff(path pointer.Pointer, a, b parser.Value, p Patch) Patch {
+	if parser.Equal(a, b) {
+		return p
+	}
+
+	switch x := a.(type) {
+	case parser.Object:
+		if y, ok := b.(parser.Object); ok {
+			for _, m := range x.Members {
+				if _, ok := y.Get(m.Key); !ok {
+					p = append(p, Operation{Op: "remove", Path: path.Append(m.Key).String()})
+				}
+			}
+			for _, m := range y.Members {
+				if v, ok := x.Get(m.Key); ok {
+					p = diff(path.Append(m.Key), v, m.Value, p)
+				} else {
+					p = append(p, Operation{Op: "add", Path: path.Append(m.Key).String(), Value: m.Value})
+				}
+			}
+			return p
+		}
+	case parser.Array:
+		if y, ok := b.(parser.Array); ok {
+			n := len(x)
+			if len(y) < n {
+				n = len(y)
+			}
+			for i := 0; i < n; i++ {
+				p = diff(path.Append(fmt.Sprint(i)), x[i], y[i], p)
+			}
+			for i := len(x) - 1; i >= n; i-- {
+				p = append(p, Operation{Op: "remove", Path: path.Append(fmt.Sprint(i)).String()})
+			}
+			for i := n; i < len(y); i++ {
+				p = append(p, Operation{Op: "add", Path: path.Append("-").String(), Value: y[i]})
+			}
+			return p
+		}
+	}
+	return append(p, Operation{Op: "replace", Path: path.String(), Value: b})
+}
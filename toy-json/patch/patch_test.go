@@ -0,0 +1,183 @@
+package patch
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+func parse(t *testing.T, s string) parser.Value {
+	t.Helper()
+	v, err := parser.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("%s: %v", s, err)
+	}
+	return v
+}
+
+func TestApply(t *testing.T) {
+	// mostly the examples of RFC 6902 appendix A
+	tests := []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz": "qux", "foo": "bar"}`},
+		{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`},
+		{`{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo": ["bar", "baz"]}`},
+		{`{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`},
+		{
+			`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo": ["all", "cows", "eat", "grass"]}`},
+		{
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`,
+		},
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`, `{"foo": "bar", "child": {"grandchild": {}}}`},
+		{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo": ["bar", ["abc", "def"]]}`},
+		{`{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": 10}]`, `{"/": 9, "~1": 10}`},
+		{`{"a": {"b": 1}}`, `[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "add", "path": "/c/d", "value": null}]`, `{"a": {"b": 1}, "c": {"b": 1, "d": null}}`},
+		{`{"a": 1}`, `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`},
+		{`{"a": {"b": 1}}`, `[{"op": "test", "path": "", "value": {"a": {"b": 1.0}}}]`, `{"a": {"b": 1}}`},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(strings.NewReader(tt.patch))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.patch, err)
+			continue
+		}
+		got, err := Apply(parse(t, tt.doc), p)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.patch, err)
+			continue
+		}
+		if expected := parse(t, tt.expected); !Equal(got, expected) {
+			t.Errorf("%s: result wrong. got=%#v, want=%#v", tt.patch, got, expected)
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, `patch operation 0 (add /baz/bat): /baz: member "baz" not found`},
+		{`{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`, "patch operation 0 (test /baz): test failed: value differs"},
+		{`{"foo": [1]}`, `[{"op": "add", "path": "/foo/2", "value": 0}]`, "patch operation 0 (add /foo/2): array index 2 out of range"},
+		{`{"foo": [1]}`, `[{"op": "remove", "path": "/foo/-"}]`, "patch operation 0 (remove /foo/-): index '-' refers past the end of the array"},
+		{`{"a": {}}`, `[{"op": "move", "from": "/a", "path": "/a/b"}]`, "patch operation 0 (move /a/b): cannot move /a into itself"},
+		{`{"a": 1}`, `[{"op": "replace", "path": "/b", "value": 2}]`, `patch operation 0 (replace /b): member "b" not found`},
+		{`{"a": 1}`, `[{"op": "remove", "path": ""}]`, "patch operation 0 (remove ): cannot remove the whole document"},
+		{`{"a": 1}`, `[{"op": "add", "path": "/a/b", "value": 2}]`, "patch operation 0 (add /a/b): cannot add to a scalar"},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(strings.NewReader(tt.patch))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.patch, err)
+			continue
+		}
+		_, err = Apply(parse(t, tt.doc), p)
+		if err == nil {
+			t.Errorf("%s: expected error %q", tt.patch, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%s: error wrong. got=%q, want=%q", tt.patch, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	const original = `{"a": {"b": [1, 2, 3]}, "c": "d"}`
+	doc := parse(t, original)
+	p, err := Parse(strings.NewReader(`[
+		{"op": "remove", "path": "/a/b/0"},
+		{"op": "replace", "path": "/c", "value": "e"},
+		{"op": "add", "path": "/a/x", "value": 1},
+		{"op": "test", "path": "/c", "value": "d"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Apply(doc, p)
+	var patchErr *Error
+	if !errors.As(err, &patchErr) || patchErr.Index != 3 || got != nil {
+		t.Fatalf("the test operation should fail. got=%#v, %v", got, err)
+	}
+	if !reflect.DeepEqual(doc, parse(t, original)) {
+		t.Errorf("the original document was modified: %#v", doc)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, "patch must be an array of operations"},
+		{`[1]`, "operation 0 is not an object"},
+		{`[{"path": "/a"}]`, `operation 0: missing "op"`},
+		{`[{"op": "add", "path": 1, "value": 1}]`, `operation 0: "path" must be a string`},
+		{`[{"op": "add", "path": "/a"}]`, "operation 0: add needs a value"},
+		{`[{"op": "copy", "path": "/a"}]`, `operation 0: missing "from"`},
+		{`[{"op": "remove", "path": ""}, {"op": "rename", "path": "/a"}]`, `operation 1: unknown op "rename"`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.input))
+		if err == nil {
+			t.Errorf("%s: expected error %q", tt.input, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%s: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := parse(t, `{"a": 1, "b": [1, 2, 3], "c": {"d": true}, "e": "x"}`)
+	b := parse(t, `{"a": 1, "b": [1, 5], "c": {"d": true, "f": null}, "g": [], "e": {"x": 1}}`)
+
+	expected := Patch{
+		{Op: "replace", Path: "/b/1", Value: parser.Number(5)},
+		{Op: "remove", Path: "/b/2"},
+		{Op: "add", Path: "/c/f", Value: parser.Null{}},
+		{Op: "add", Path: "/g", Value: parser.Array{}},
+		{Op: "replace", Path: "/e", Value: parser.Object{Members: []parser.Member{{Key: "x", Value: parser.Number(1)}}}},
+	}
+	if got := Diff(a, b); !reflect.DeepEqual(got, expected) {
+		t.Errorf("diff wrong.\ngot= %#v\nwant=%#v", got, expected)
+	}
+
+	pairs := [][2]string{
+		{`{"a": 1, "b": [1, 2, 3]}`, `{"b": [1, 2, 3, 4, {"x": [1]}], "c": 2}`},
+		{`[1, [2, 3], {"a": 1}]`, `[1, [3], {"a": 2, "b/c~": 3}]`},
+		{`{"a": 1}`, `[1]`},
+		{`"x"`, `"x"`},
+	}
+	for _, pair := range pairs {
+		a, b := parse(t, pair[0]), parse(t, pair[1])
+		got, err := Apply(a, Diff(a, b))
+		if err != nil {
+			t.Errorf("%s -> %s: unexpected error: %v", pair[0], pair[1], err)
+			continue
+		}
+		if !Equal(got, b) {
+			t.Errorf("%s -> %s: result wrong. got=%#v", pair[0], pair[1], got)
+		}
+	}
+}
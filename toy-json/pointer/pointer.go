@@ -0,0 +1,122 @@
+// Package pointer implements JSON Pointer (RFC 6901) over parser.Value trees.
+package pointer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// Pointer is a parsed JSON Pointer: its reference tokens with ~0 and ~1 already unescaped.
+// The empty Pointer refers to the whole document.
+type Pointer []string
+
+// Parse parses a pointer such as "/a/b/0". The empty string is the whole document.
+func Parse(s string) (Pointer, error) {
+	if s == "" {
+		return Pointer{}, nil
+	}
+	if s[0] != '/' {
+		return nil, fmt.Errorf("pointer %q must start with '/'", s)
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, tok := range tokens {
+		for j := 0; j < len(tok); j++ {
+			if tok[j] == '~' && (j+1 == len(tok) || tok[j+1] != '0' && tok[j+1] != '1') {
+				return nil, fmt.Errorf("pointer %q has an invalid escape: '~' must be followed by 0 or 1", s)
+			}
+		}
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+	}
+	return Pointer(tokens), nil
+}
+
+// MustParse is Parse that panics on an invalid pointer, for constants in code.
+func MustParse(s string) Pointer {
+	p, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the pointer in its escaped form.
+func (p Pointer) String() string {
+	var b strings.Builder
+	for _, tok := range p {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(tok))
+	}
+	return b.String()
+}
+
+// Parent returns the pointer to the value holding the target. p must not be empty.
+func (p Pointer) Parent() Pointer {
+	return p[:len(p)-1]
+}
+
+// Last returns the final reference token. p must not be empty.
+func (p Pointer) Last() string {
+	return p[len(p)-1]
+}
+
+// Append returns a new pointer with tok added at the end.
+func (p Pointer) Append(tok string) Pointer {
+	return append(append(Pointer{}, p...), tok)
+}
+
+// HasPrefix reports whether p is q or points inside it.
+func (p Pointer) HasPrefix(q Pointer) bool {
+	if len(q) > len(p) {
+		return false
+	}
+	for i := range q {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the value p refers to in doc.
+func (p Pointer) Get(doc parser.Value) (parser.Value, error) {
+	v := doc
+	for i, tok := range p {
+		switch c := v.(type) {
+		case parser.Object:
+			child, ok := c.Get(tok)
+			if !ok {
+				return nil, fmt.Errorf("%s: member %q not found", p[:i+1], tok)
+			}
+			v = child
+		case parser.Array:
+			n, err := Index(tok, len(c))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", p[:i+1], err)
+			}
+			v = c[n]
+		default:
+			return nil, fmt.Errorf("%s: cannot index into a scalar", p[:i+1])
+		}
+	}
+	return v, nil
+}
+
+// Index converts a reference token to an index of an array of length n.
+// The token must be "0" or a number without leading zeros, and below n.
+func Index(tok string, n int) (int, error) {
+	if tok == "-" {
+		return 0, fmt.Errorf("index '-' refers past the end of the array")
+	}
+	if tok == "" || len(tok) > 1 && tok[0] == '0' || strings.TrimLeft(tok, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i >= n {
+		return 0, fmt.Errorf("array index %s out of range", tok)
+	}
+	return i, nil
+}
@@ -0,0 +1,113 @@
+package pointer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+// the example document of RFC 6901 section 5
+const rfcDocument = `{
+	"foo": ["bar", "baz"],
+	"": 0,
+	"a/b": 1,
+	"c%d": 2,
+	"e^f": 3,
+	"g|h": 4,
+	"i\\j": 5,
+	"k\"l": 6,
+	" ": 7,
+	"m~n": 8
+}`
+
+func TestGet(t *testing.T) {
+	doc, err := parser.Parse(strings.NewReader(rfcDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected parser.Value
+	}{
+		{"", doc},
+		{"/foo", parser.Array{parser.String("bar"), parser.String("baz")}},
+		{"/foo/0", parser.String("bar")},
+		{"/", parser.Number(0)},
+		{"/a~1b", parser.Number(1)},
+		{"/c%d", parser.Number(2)},
+		{"/e^f", parser.Number(3)},
+		{"/g|h", parser.Number(4)},
+		{`/i\j`, parser.Number(5)},
+		{`/k"l`, parser.Number(6)},
+		{"/ ", parser.Number(7)},
+		{"/m~0n", parser.Number(8)},
+	}
+
+	for _, tt := range tests {
+		p, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if p.String() != tt.input {
+			t.Errorf("%q: String wrong. got=%q", tt.input, p.String())
+		}
+		v, err := p.Get(doc)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(v, tt.expected) {
+			t.Errorf("%q: value wrong. got=%#v, want=%#v", tt.input, v, tt.expected)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse("/a~01/~10/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Pointer{"a~1", "/0", ""}); !reflect.DeepEqual(p, expected) {
+		t.Errorf("tokens wrong. got=%q, want=%q", p, expected)
+	}
+
+	for _, input := range []string{"a", "/~", "/~2", "/a~"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestGetErrors(t *testing.T) {
+	doc, err := parser.Parse(strings.NewReader(rfcDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"/nope", `/nope: member "nope" not found`},
+		{"/foo/2", "/foo/2: array index 2 out of range"},
+		{"/foo/01", `/foo/01: invalid array index "01"`},
+		{"/foo/-", "/foo/-: index '-' refers past the end of the array"},
+		{"/foo/+1", `/foo/+1: invalid array index "+1"`},
+		{"/foo/0/x", "/foo/0/x: cannot index into a scalar"},
+	}
+
+	for _, tt := range tests {
+		_, err := MustParse(tt.input).Get(doc)
+		if err == nil {
+			t.Errorf("%q: expected error %q", tt.input, tt.expected)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package toyjson
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/tMinamiii/various-parser/toy-json/lexer"
+)
+
+// Token is one step of a JSON stream returned by Decoder.Token:
+// Delim for the four brackets, bool, float64, string for strings and keys, or nil for null.
+type Token interface{}
+
+// Delim is one of [ ] { }.
+type Delim rune
+
+func (d Delim) String() string {
+	return string(d)
+}
+
+// where the decoder is in the value it is walking through Token
+type tokenState int8
+
+const (
+	tokenTopValue tokenState = iota
+	tokenArrayStart
+	tokenArrayValue
+	tokenArrayComma
+	tokenObjectStart
+	tokenObjectKey
+	tokenObjectColon
+	tokenObjectValue
+	tokenObjectComma
+)
+
+// Decoder reads JSON values from a stream.
+//
+// Only the current token is kept in memory, so a huge top-level array can be walked
+// one element at a time with Token to enter it, More and Decode to read each element,
+// and Token again for the closing bracket. Several top-level values may follow each other.
+// Each value read by Decode may nest up to parser.MaxDepth deep; arrays and objects
+// entered with Token are not counted.
+type Decoder struct {
+	d          decodeState
+	state      tokenState
+	stateStack []tokenState
+}
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{d: decodeState{lexer: lexer.NewLexer(lexer.NewScanner(r))}}
+}
+
+// Decode reads the next value into v, following the same rules as Unmarshal.
+// It returns io.EOF when there are no more top-level values.
+func (dec *Decoder) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &InvalidUnmarshalError{Type: reflect.TypeOf(v)}
+	}
+
+	if err := dec.prepareForValue(); err != nil {
+		return err
+	}
+	if dec.d.tok == nil && dec.state == tokenTopValue {
+		return io.EOF
+	}
+	if !dec.valueAllowed() {
+		return dec.d.unexpected("value")
+	}
+
+	dec.d.path = dec.d.path[:0]
+	if err := dec.d.value(rv); err != nil {
+		return err
+	}
+	dec.valueEnd()
+	return nil
+}
+
+// More reports whether the current array or object has another element.
+func (dec *Decoder) More() bool {
+	if err := dec.d.peek(); err != nil {
+		return false
+	}
+	return dec.d.tok != nil && !dec.d.is(lexer.TokenRightBracket) && !dec.d.is(lexer.TokenRightBrace)
+}
+
+// Token returns the next token. Commas and colons are checked and skipped.
+// It returns io.EOF at the end of the input between top-level values.
+func (dec *Decoder) Token() (Token, error) {
+	d := &dec.d
+	for {
+		if err := d.peek(); err != nil {
+			return nil, err
+		}
+		if d.tok == nil {
+			if dec.state == tokenTopValue && len(dec.stateStack) == 0 {
+				return nil, io.EOF
+			}
+			return nil, d.unexpected(dec.expected())
+		}
+
+		switch d.tok.Type() {
+		case lexer.TokenLeftBracket, lexer.TokenLeftBrace:
+			if !dec.valueAllowed() {
+				return nil, d.unexpected(dec.expected())
+			}
+			delim := Delim('[')
+			next := tokenArrayStart
+			if d.is(lexer.TokenLeftBrace) {
+				delim, next = '{', tokenObjectStart
+			}
+			d.consume()
+			dec.stateStack = append(dec.stateStack, dec.state)
+			dec.state = next
+			return delim, nil
+
+		case lexer.TokenRightBracket:
+			if dec.state != tokenArrayStart && dec.state != tokenArrayComma {
+				return nil, d.unexpected(dec.expected())
+			}
+			d.consume()
+			dec.pop()
+			return Delim(']'), nil
+
+		case lexer.TokenRightBrace:
+			if dec.state != tokenObjectStart && dec.state != tokenObjectComma {
+				return nil, d.unexpected(dec.expected())
+			}
+			d.consume()
+			dec.pop()
+			return Delim('}'), nil
+
+		case lexer.TokenComma:
+			switch dec.state {
+			case tokenArrayComma:
+				dec.state = tokenArrayValue
+			case tokenObjectComma:
+				dec.state = tokenObjectKey
+			default:
+				return nil, d.unexpected(dec.expected())
+			}
+			d.consume()
+
+		case lexer.TokenColon:
+			if dec.state != tokenObjectColon {
+				return nil, d.unexpected(dec.expected())
+			}
+			dec.state = tokenObjectValue
+			d.consume()
+
+		case lexer.TokenString:
+			s := d.tok.String()
+			if dec.state == tokenObjectStart || dec.state == tokenObjectKey {
+				d.consume()
+				dec.state = tokenObjectColon
+				return s, nil
+			}
+			if !dec.valueAllowed() {
+				return nil, d.unexpected(dec.expected())
+			}
+			d.consume()
+			dec.valueEnd()
+			return s, nil
+
+		default:
+			if !dec.valueAllowed() {
+				return nil, d.unexpected(dec.expected())
+			}
+			var tok Token
+			switch d.tok.Type() {
+			case lexer.TokenTrue:
+				tok = true
+			case lexer.TokenFalse:
+				tok = false
+			case lexer.TokenNumber:
+				tok = d.tok.Number()
+			}
+			d.consume()
+			dec.valueEnd()
+			return tok, nil
+		}
+	}
+}
+
+// prepareForValue skips the comma or colon that comes before a value passed to Decode.
+func (dec *Decoder) prepareForValue() error {
+	d := &dec.d
+	if err := d.peek(); err != nil {
+		return err
+	}
+	switch {
+	case dec.state == tokenArrayComma && d.is(lexer.TokenComma):
+		dec.state = tokenArrayValue
+	case dec.state == tokenObjectColon && d.is(lexer.TokenColon):
+		dec.state = tokenObjectValue
+	default:
+		return nil
+	}
+	return d.next()
+}
+
+func (dec *Decoder) valueAllowed() bool {
+	switch dec.state {
+	case tokenTopValue, tokenArrayStart, tokenArrayValue, tokenObjectValue:
+		return true
+	}
+	return false
+}
+
+// valueEnd moves past a complete value.
+func (dec *Decoder) valueEnd() {
+	switch dec.state {
+	case tokenArrayStart, tokenArrayValue:
+		dec.state = tokenArrayComma
+	case tokenObjectValue:
+		dec.state = tokenObjectComma
+	}
+}
+
+// pop returns to the enclosing value after a closing bracket.
+func (dec *Decoder) pop() {
+	dec.state = dec.stateStack[len(dec.stateStack)-1]
+	dec.stateStack = dec.stateStack[:len(dec.stateStack)-1]
+	dec.valueEnd()
+}
+
+func (dec *Decoder) expected() string {
+	switch dec.state {
+	case tokenArrayStart:
+		return "value or ']'"
+	case tokenArrayComma:
+		return "',' or ']'"
+	case tokenObjectStart:
+		return "string key or '}'"
+	case tokenObjectKey:
+		return "string key"
+	case tokenObjectColon:
+		return "':'"
+	case tokenObjectComma:
+		return "',' or '}'"
+	}
+	return "value"
+}
+
+// LineDecoder reads newline-delimited JSON (NDJSON, JSON Lines): one value per line.
+// Blank lines are skipped. Only one line is held in memory at a time.
+type LineDecoder struct {
+	r      *bufio.Reader
+	line   int
+	offset int // byte offset of the next line
+}
+
+// NewLineDecoder returns a decoder reading one JSON value per line from r.
+func NewLineDecoder(r io.Reader) *LineDecoder {
+	return &LineDecoder{r: bufio.NewReader(r)}
+}
+
+// Line is the number of the line read by the last call to Decode, starting at 1.
+func (dec *LineDecoder) Line() int {
+	return dec.line
+}
+
+// Decode reads the next non-blank line into v. It returns io.EOF after the last line.
+// A *SyntaxError has its position moved to where the line sits in the whole stream;
+// other errors are wrapped with the line number, and errors.As still finds *UnmarshalTypeError.
+func (dec *LineDecoder) Decode(v interface{}) error {
+	for {
+		b, err := dec.r.ReadBytes('\n')
+		if err != nil && !(errors.Is(err, io.EOF) && len(b) > 0) {
+			return err
+		}
+		dec.line++
+		start := dec.offset
+		dec.offset += len(b)
+
+		b = bytes.TrimRight(b, "\r\n")
+		if len(bytes.Trim(b, " \t")) == 0 {
+			continue
+		}
+		err = Unmarshal(b, v)
+		var syntaxErr *SyntaxError
+		if errors.As(err, &syntaxErr) {
+			syntaxErr.Pos.Line = dec.line
+			syntaxErr.Pos.Offset += start
+			return syntaxErr
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", dec.line, err)
+		}
+		return nil
+	}
+}
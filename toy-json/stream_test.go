@@ -0,0 +1,300 @@
+package toyjson
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/tMinamiii/various-parser/toy-json/parser"
+)
+
+func TestDecoderToken(t *testing.T) {
+	input := ` {"a": [1, "x", true, null], "b": {}} [] "s"`
+	expected := []Token{
+		Delim('{'), "a", Delim('['), 1.0, "x", true, nil, Delim(']'), "b", Delim('{'), Delim('}'), Delim('}'),
+		Delim('['), Delim(']'),
+		"s",
+	}
+
+	dec := NewDecoder(strings.NewReader(input))
+	for i, want := range expected {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("tokens[%d]: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(tok, want) {
+			t.Errorf("tokens[%d] wrong. got=%#v, want=%#v", i, tok, want)
+		}
+	}
+	if tok, err := dec.Token(); err != io.EOF {
+		t.Errorf("expected io.EOF at the end. got=%#v, %v", tok, err)
+	}
+}
+
+func TestDecoderTokenErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1 2]", "1:4: unexpected number, expected ',' or ']'"},
+		{"[1,]", "1:4: unexpected ']', expected value"},
+		{`{"a" 1}`, "1:6: unexpected number, expected ':'"},
+		{`{1: 2}`, "1:2: unexpected number, expected string key or '}'"},
+		{"[}", "1:2: unexpected '}', expected value or ']'"},
+		{"[1", "1:3: unexpected end of input, expected ',' or ']'"},
+		{"]", "1:1: unexpected ']', expected value"},
+	}
+
+	for _, tt := range tests {
+		dec := NewDecoder(strings.NewReader(tt.input))
+		var err error
+		for err == nil {
+			_, err = dec.Token()
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("%q: error wrong. got=%q, want=%q", tt.input, err.Error(), tt.expected)
+		}
+	}
+}
+
+func TestDecoderElements(t *testing.T) {
+	input := `{"total": 3, "items": [{"id": 1}, {"id": 2, "note": "b"}, {"id": 3}]}`
+	dec := NewDecoder(strings.NewReader(input))
+
+	for _, want := range []Token{Delim('{'), "total"} {
+		if tok, err := dec.Token(); err != nil || tok != want {
+			t.Fatalf("token wrong. got=%#v, %v, want=%#v", tok, err, want)
+		}
+	}
+	var total int
+	if err := dec.Decode(&total); err != nil || total != 3 {
+		t.Fatalf("total wrong. got=%d, %v", total, err)
+	}
+	for _, want := range []Token{"items", Delim('[')} {
+		if tok, err := dec.Token(); err != nil || tok != want {
+			t.Fatalf("token wrong. got=%#v, %v, want=%#v", tok, err, want)
+		}
+	}
+
+	var got []Base
+	for dec.More() {
+		var b Base
+		if err := dec.Decode(&b); err != nil {
+			t.Fatalf("element %d: %v", len(got), err)
+		}
+		got = append(got, b)
+	}
+	expected := []Base{{ID: 1}, {ID: 2, Note: "b"}, {ID: 3}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("elements wrong. got=%#v, want=%#v", got, expected)
+	}
+
+	for _, want := range []Token{Delim(']'), Delim('}')} {
+		if tok, err := dec.Token(); err != nil || tok != want {
+			t.Fatalf("token wrong. got=%#v, %v, want=%#v", tok, err, want)
+		}
+	}
+	if dec.More() {
+		t.Errorf("More should be false at the end of input")
+	}
+}
+
+func TestDecoderValues(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`"shout" {"name": "x"} 7`))
+
+	var up upper
+	var in Inner
+	var n int
+	for _, v := range []interface{}{&up, &in, &n} {
+		if err := dec.Decode(v); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if up != "SHOUT" || in.Name != "x" || n != 7 {
+		t.Errorf("values wrong. got=%q %#v %d", up, in, n)
+	}
+	if err := dec.Decode(&n); err != io.EOF {
+		t.Errorf("expected io.EOF at the end. got=%v", err)
+	}
+
+	dec = NewDecoder(strings.NewReader(`{"id": "x"}`))
+	var b Base
+	var typeErr *UnmarshalTypeError
+	if err := dec.Decode(&b); !errors.As(err, &typeErr) || typeErr.Path != "$.id" || typeErr.Offset != 7 {
+		t.Errorf("expected a type error at $.id. got=%v", err)
+	}
+}
+
+func TestLineDecoder(t *testing.T) {
+	input := "{\"id\": 1}\r\n\n  \n{\"id\": 2, \"Note\": \"x\"}\n{\"id\": \"3\"}\n{\"id\": 4}"
+	dec := NewLineDecoder(strings.NewReader(input))
+
+	var b Base
+	if err := dec.Decode(&b); err != nil || b.ID != 1 || dec.Line() != 1 {
+		t.Fatalf("line 1 wrong. got=%#v, %v, line %d", b, err, dec.Line())
+	}
+	b = Base{}
+	if err := dec.Decode(&b); err != nil || b != (Base{ID: 2, Note: "x"}) || dec.Line() != 4 {
+		t.Fatalf("line 4 wrong. got=%#v, %v, line %d", b, err, dec.Line())
+	}
+	err := dec.Decode(&b)
+	var typeErr *UnmarshalTypeError
+	if !errors.As(err, &typeErr) || !strings.HasPrefix(err.Error(), "line 5: ") {
+		t.Errorf("line 5 should fail with a type error. got=%v", err)
+	}
+	if err := dec.Decode(&b); err != nil || b.ID != 4 {
+		t.Errorf("last line without newline wrong. got=%#v, %v", b, err)
+	}
+	if err := dec.Decode(&b); err != io.EOF {
+		t.Errorf("expected io.EOF at the end. got=%v", err)
+	}
+
+	dec = NewLineDecoder(strings.NewReader("{}\n\n[1 2]\n"))
+	var any interface{}
+	if err := dec.Decode(&any); err != nil {
+		t.Fatal(err)
+	}
+	err = dec.Decode(&any)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || err.Error() != "3:4: unexpected number, expected ',' or ']'" || syntaxErr.Pos.Offset != 7 {
+		t.Errorf("syntax errors should point into the whole stream. got=%v", err)
+	}
+}
+
+func TestDecoderMaxDepth(t *testing.T) {
+	// one element of a streamed array nested far deeper than parser.MaxDepth
+	deep := strings.Repeat("[", 1000000) + strings.Repeat("]", 1000000)
+	dec := NewDecoder(strings.NewReader("[1, " + deep + "]"))
+	if _, err := dec.Token(); err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	err := dec.Decode(&v)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || err.Error() != "1:10005: exceeded max depth of 10000" {
+		t.Errorf("deep element should fail with a syntax error. got=%v", err)
+	}
+
+	// the limit applies to each value, not to the whole stream
+	ok := strings.Repeat("[", parser.MaxDepth) + strings.Repeat("]", parser.MaxDepth)
+	dec = NewDecoder(strings.NewReader(ok + ok))
+	for i := 0; i < 2; i++ {
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("value %d at max depth: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestLineDecoderMaxDepth(t *testing.T) {
+	deep := strings.Repeat("[", 1000000) + strings.Repeat("]", 1000000)
+	dec := NewLineDecoder(strings.NewReader("1\n" + deep + "\n2\n"))
+	var n interface{}
+	if err := dec.Decode(&n); err != nil {
+		t.Fatal(err)
+	}
+	err := dec.Decode(&n)
+	var syntaxErr *SyntaxError
+	if !errors.As(err, &syntaxErr) || err.Error() != "2:10001: exceeded max depth of 10000" {
+		t.Errorf("deep line should fail with a syntax error. got=%v", err)
+	}
+	if err := dec.Decode(&n); err != nil || n != 2.0 {
+		t.Errorf("line after the deep one wrong. got=%v, %v", n, err)
+	}
+}
+
+// arrayReader produces a JSON array of n objects without ever holding the whole document.
+type arrayReader struct {
+	n, i int
+	buf  []byte
+}
+
+func (r *arrayReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		switch {
+		case r.i == 0:
+			r.buf = []byte("[")
+		case r.i <= r.n:
+			r.buf = []byte(fmt.Sprintf(`{"id": %d, "Note": "element number %d"}`, r.i, r.i))
+			if r.i < r.n {
+				r.buf = append(r.buf, ',', '\n')
+			}
+		case r.i == r.n+1:
+			r.buf = []byte("]")
+		default:
+			return 0, io.EOF
+		}
+		r.i++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// liveHeap returns the bytes still reachable after a collection.
+func liveHeap() uint64 {
+	var m runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+// BenchmarkDecoderArray walks arrays of growing size element by element.
+// peak-heap-KB stays about the same for every size, while Unmarshal's grows with the input.
+func BenchmarkDecoderArray(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("Decoder/n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			base := liveHeap()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				dec := NewDecoder(&arrayReader{n: n})
+				if _, err := dec.Token(); err != nil {
+					b.Fatal(err)
+				}
+				for j := 0; dec.More(); j++ {
+					var e Base
+					if err := dec.Decode(&e); err != nil {
+						b.Fatal(err)
+					}
+					if j%(n/10) == 0 {
+						if h := liveHeap(); h > peak {
+							peak = h
+						}
+					}
+				}
+			}
+			b.ReportMetric((float64(peak)-float64(base))/1024, "peak-heap-KB")
+		})
+	}
+
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("Unmarshal/n=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			base := liveHeap()
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				data, err := io.ReadAll(&arrayReader{n: n})
+				if err != nil {
+					b.Fatal(err)
+				}
+				var es []Base
+				if err := Unmarshal(data, &es); err != nil {
+					b.Fatal(err)
+				}
+				if h := liveHeap(); h > peak {
+					peak = h
+				}
+				runtime.KeepAlive(data)
+				runtime.KeepAlive(es)
+			}
+			b.ReportMetric((float64(peak)-float64(base))/1024, "peak-heap-KB")
+		})
+	}
+}